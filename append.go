@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"math"
 	"strconv"
+	"sync/atomic"
 	"unicode/utf8"
 )
 
@@ -11,6 +12,10 @@ const (
 	_hex             = "0123456789abcdef"
 	digits           = "0123456789abcdefghijklmnopqrstuvwxyz"
 	initialFloatSize = 24
+
+	invalidJSONSuffix  = "_invalid_json"
+	truncatedKey       = "_truncated"
+	truncatedFieldsKey = "_truncated_fields"
 )
 
 var shifts = [len(digits) + 1]uint{
@@ -21,59 +26,119 @@ var shifts = [len(digits) + 1]uint{
 	1 << 5: 5,
 }
 
-func appendKeyValue(b *bytes.Buffer, k, v []byte) {
-	b.WriteByte('"')
-	b.Write(k)
-	b.WriteByte('"')
-	b.WriteByte(':')
-	b.WriteByte('"')
-	b.Write(v)
-	b.WriteByte('"')
-	b.WriteByte(',')
-	b.WriteByte(' ')
+var truncations uint64
+
+// Truncations returns how many entries have had their message, fields or line truncated because of the size
+// limits.
+func Truncations() uint64 {
+	return atomic.LoadUint64(&truncations)
 }
 
-func appendString(b *bytes.Buffer, key, val string) {
-	b.WriteByte('"')
-	safeAppendString(b, key)
-	b.WriteByte('"')
-	b.WriteByte(':')
+func countTruncation() {
+	atomic.AddUint64(&truncations, 1)
+}
+
+// truncate shortens s to at most max bytes without splitting a rune, ending it with a truncation marker.
+func truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	return cutString(s, max)
+}
+
+// cutString keeps the first max bytes of s, backing off to the start of a rune, and marks what was cut.
+func cutString(s string, max int) string {
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + truncationMarker(len(s)-cut)
+}
+
+// truncate is truncate for a value of the entry in b, noting in b when the value was cut.
+func (b *Buffer) truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	b.truncated = true
+	return cutString(s, max)
+}
+
+func truncationMarker(n int) string {
+	return "…(truncated " + strconv.Itoa(n) + " bytes)"
+}
+
+// encodeLimited encodes the field unless it would grow the buffer past limit, returning the bytes dropped.
+func encodeLimited(enc Encoder, b *Buffer, f Field, limit int) int {
+	mark, auxMark, count := b.Len(), b.aux.Len(), b.Top().Count
+	f.encode(enc, b)
+	if limit > 0 && b.Len() > limit {
+		n := b.Len() - mark
+		b.Truncate(mark)
+		b.aux.Truncate(auxMark)
+		b.Top().Count = count
+		return n
+	}
+	return 0
+}
+
+// limitWrite is encodeLimited for values an encoder writes itself, such as the fields it held back.
+func limitWrite(b *Buffer, limit int, write func()) int {
+	mark, auxMark, count := b.Len(), b.aux.Len(), b.Top().Count
+	write()
+	if limit > 0 && b.Len() > limit {
+		n := b.Len() - mark
+		b.Truncate(mark)
+		b.aux.Truncate(auxMark)
+		b.Top().Count = count
+		return n
+	}
+	return 0
+}
+
+// appendLimitedBytes is encodeLimited for a field that was already encoded into count values.
+func appendLimitedBytes(b *Buffer, encoded []byte, count, limit int) int {
+	if limit > 0 && b.Len()+len(encoded) > limit {
+		return len(encoded)
+	}
+	b.Write(encoded)
+	b.Top().Count += count
+	return 0
+}
+
+func appendStringValue(b *bytes.Buffer, val string) {
 	b.WriteByte('"')
 	safeAppendString(b, val)
 	b.WriteByte('"')
-	b.WriteByte(',')
-	b.WriteByte(' ')
 }
 
-func appendJsonString(b *bytes.Buffer, key, val string) {
-	b.WriteByte('"')
-	safeAppendString(b, key)
-	b.WriteByte('"')
-	b.WriteByte(':')
+func appendJsonStringValue(b *bytes.Buffer, val string) {
 	b.WriteByte('"')
 	safeAppendJsonString(b, val)
 	b.WriteByte('"')
-	b.WriteByte(',')
-	b.WriteByte(' ')
 }
 
-func appendRaw(b *bytes.Buffer, key string, raw []byte) {
+// appendStrictStringValue writes val as a JSON string the way encoding/json reads it back, for the formats fed to
+// strict parsers.
+func appendStrictStringValue(b *bytes.Buffer, val string) {
 	b.WriteByte('"')
-	safeAppendString(b, key)
+	safeAppendStrictString(b, val)
 	b.WriteByte('"')
-	b.WriteByte(':')
-	for _, char := range raw {
-		b.WriteByte(char)
+}
+
+// appendStrictFloatValue writes val as a JSON number, or as a string for NaN and the infinities which JSON has no
+// numbers for.
+func appendStrictFloatValue(b *bytes.Buffer, val float64) {
+	if math.IsNaN(val) || math.IsInf(val, 0) {
+		b.WriteByte('"')
+		appendFloatValue(b, val)
+		b.WriteByte('"')
+		return
 	}
-	b.WriteByte(',')
-	b.WriteByte(' ')
+	appendFloatValue(b, val)
 }
 
-func appendBool(b *bytes.Buffer, key string, val bool) {
-	b.WriteByte('"')
-	safeAppendString(b, key)
-	b.WriteByte('"')
-	b.WriteByte(':')
+func appendBoolValue(b *bytes.Buffer, val bool) {
 	b.WriteByte('"')
 	if val {
 		b.WriteString("true")
@@ -81,48 +146,17 @@ func appendBool(b *bytes.Buffer, key string, val bool) {
 		b.WriteString("false")
 	}
 	b.WriteByte('"')
-	b.WriteByte(',')
-	b.WriteByte(' ')
 }
 
-func appendInt(b *bytes.Buffer, key string, val int) {
-	appendInt64(b, key, int64(val))
-}
-
-func appendInt64(b *bytes.Buffer, key string, val int64) {
-	b.WriteByte('"')
-	safeAppendString(b, key)
-	b.WriteByte('"')
-	b.WriteByte(':')
+func appendInt64Value(b *bytes.Buffer, val int64) {
 	formatBits(b, uint64(val), 10, val < 0)
-	b.WriteByte(',')
-	b.WriteByte(' ')
-}
-
-func appendUint(b *bytes.Buffer, key string, val uint) {
-	appendUint64(b, key, uint64(val))
-}
-
-func appendUint64(b *bytes.Buffer, key string, val uint64) {
-	b.WriteByte('"')
-	safeAppendString(b, key)
-	b.WriteByte('"')
-	b.WriteByte(':')
-	formatBits(b, uint64(val), 10, false)
-	b.WriteByte(',')
-	b.WriteByte(' ')
 }
 
-func appendUintptr(b *bytes.Buffer, key string, val uintptr) {
-	appendUint64(b, key, uint64(val))
+func appendUint64Value(b *bytes.Buffer, val uint64) {
+	formatBits(b, val, 10, false)
 }
 
-func appendFloat64(b *bytes.Buffer, key string, val float64) {
-	b.WriteByte('"')
-	safeAppendString(b, key)
-	b.WriteByte('"')
-	b.WriteByte(':')
-
+func appendFloatValue(b *bytes.Buffer, val float64) {
 	switch {
 	case math.IsNaN(val):
 		b.WriteString("NaN")
@@ -133,8 +167,6 @@ func appendFloat64(b *bytes.Buffer, key string, val float64) {
 	default:
 		b.Write(strconv.AppendFloat(make([]byte, 0, initialFloatSize), val, 'f', -1, 64))
 	}
-	b.WriteByte(',')
-	b.WriteByte(' ')
 }
 
 func safeAppendString(buf *bytes.Buffer, s string) {
@@ -177,6 +209,46 @@ func safeAppendString(buf *bytes.Buffer, s string) {
 	}
 }
 
+func safeAppendStrictString(buf *bytes.Buffer, s string) {
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			i++
+			if 0x20 <= b && b != '\\' && b != '"' {
+				buf.WriteByte(b)
+				continue
+			}
+			switch b {
+			case '\\', '"':
+				buf.WriteByte('\\')
+				buf.WriteByte(b)
+			case '\n':
+				buf.WriteByte('\\')
+				buf.WriteByte('n')
+			case '\r':
+				buf.WriteByte('\\')
+				buf.WriteByte('r')
+			case '\t':
+				buf.WriteByte('\\')
+				buf.WriteByte('t')
+			default:
+				// Encode bytes < 0x20, except for the escape sequences above.
+				buf.WriteString(`\u00`)
+				buf.WriteByte(_hex[b>>4])
+				buf.WriteByte(_hex[b&0xF])
+			}
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			buf.WriteString(`\ufffd`)
+			i++
+			continue
+		}
+		buf.WriteString(s[i : i+size])
+		i += size
+	}
+}
+
 func safeAppendJsonString(buf *bytes.Buffer, s string) {
 	for i := 0; i < len(s); {
 		if b := s[i]; b < utf8.RuneSelf {
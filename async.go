@@ -0,0 +1,181 @@
+package slog
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy decides what an AsyncWriteSyncer does with a write when its queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for room in the queue.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropNewest drops the entry being written.
+	OverflowDropNewest
+
+	// OverflowDropOldest drops the oldest queued entry to make room.
+	OverflowDropOldest
+)
+
+var errAsyncClosed = errors.New("slog: write to closed AsyncWriteSyncer")
+
+// AsyncWriteSyncer queues writes and hands them to the wrapped WriteSyncer from a background goroutine, so a slow
+// writer doesn't hold up the goroutines logging. `Sync` waits for everything written before it to be flushed.
+type AsyncWriteSyncer struct {
+	ws      WriteSyncer
+	policy  OverflowPolicy
+	dropped uint64
+
+	// wsMu serializes the calls to ws, so it need not be safe for concurrent use.
+	wsMu sync.Mutex
+
+	mu       sync.Mutex
+	changed  *sync.Cond
+	queue    [][]byte
+	head     int
+	size     int
+	queued   uint64 // entries that entered the queue
+	finished uint64 // entries that left the queue, written or dropped
+	err      error
+	closed   bool
+	done     chan struct{}
+}
+
+// NewAsyncWriteSyncer wraps ws with a queue of up to size entries, handling a full queue with policy.
+func NewAsyncWriteSyncer(ws WriteSyncer, size int, policy OverflowPolicy) *AsyncWriteSyncer {
+	if size < 1 {
+		size = 1
+	}
+
+	a := &AsyncWriteSyncer{ws: ws, policy: policy, queue: make([][]byte, size), done: make(chan struct{})}
+	a.changed = sync.NewCond(&a.mu)
+	go a.run()
+	return a
+}
+
+// Write queues a copy of p.
+func (a *AsyncWriteSyncer) Write(p []byte) (int, error) {
+	entry := make([]byte, len(p))
+	copy(entry, p)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for !a.closed && a.size == len(a.queue) {
+		switch a.policy {
+		case OverflowDropNewest:
+			atomic.AddUint64(&a.dropped, 1)
+			return len(p), nil
+		case OverflowDropOldest:
+			a.pop()
+			a.finished++
+			atomic.AddUint64(&a.dropped, 1)
+		default:
+			a.changed.Wait()
+		}
+	}
+	if a.closed {
+		return 0, errAsyncClosed
+	}
+
+	a.queue[(a.head+a.size)%len(a.queue)] = entry
+	a.size++
+	a.queued++
+	a.changed.Broadcast()
+	return len(p), nil
+}
+
+// Sync waits for the entries written so far to be handed to the wrapped WriteSyncer, then syncs it. It returns
+// the first error from either since the last Sync.
+func (a *AsyncWriteSyncer) Sync() error {
+	a.mu.Lock()
+	for target := a.queued; a.finished < target; {
+		a.changed.Wait()
+	}
+	err := a.err
+	a.err = nil
+	a.mu.Unlock()
+
+	a.wsMu.Lock()
+	serr := a.ws.Sync()
+	a.wsMu.Unlock()
+
+	if err == nil {
+		err = serr
+	}
+	return err
+}
+
+// Close writes out the queued entries and stops the background goroutine. Later writes fail.
+func (a *AsyncWriteSyncer) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	a.changed.Broadcast()
+	a.mu.Unlock()
+
+	<-a.done
+	return a.Sync()
+}
+
+// Dropped returns the number of entries dropped because the queue was full.
+func (a *AsyncWriteSyncer) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// run writes the queued entries until the AsyncWriteSyncer is closed and drained.
+func (a *AsyncWriteSyncer) run() {
+	defer close(a.done)
+
+	var batch [][]byte
+	for {
+		a.mu.Lock()
+		for a.size == 0 && !a.closed {
+			a.changed.Wait()
+		}
+		if a.size == 0 {
+			a.mu.Unlock()
+			return
+		}
+
+		batch = batch[:0]
+		for a.size > 0 {
+			batch = append(batch, a.pop())
+		}
+		a.changed.Broadcast()
+		a.mu.Unlock()
+
+		var err error
+		a.wsMu.Lock()
+		for i, entry := range batch {
+			if _, werr := a.ws.Write(entry); err == nil {
+				err = werr
+			}
+			batch[i] = nil
+		}
+		a.wsMu.Unlock()
+
+		a.mu.Lock()
+		a.finished += uint64(len(batch))
+		if a.err == nil {
+			a.err = err
+		}
+		a.changed.Broadcast()
+		a.mu.Unlock()
+	}
+}
+
+// pop removes the oldest entry. Callers must hold mu.
+func (a *AsyncWriteSyncer) pop() []byte {
+	entry := a.queue[a.head]
+	a.queue[a.head] = nil
+	a.head = (a.head + 1) % len(a.queue)
+	a.size--
+	return entry
+}
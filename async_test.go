@@ -0,0 +1,177 @@
+package slog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gatedSyncer blocks writes until its gate is opened.
+type gatedSyncer struct {
+	gate    chan struct{}
+	started chan struct{}
+	once    sync.Once
+
+	mu sync.Mutex
+	recordingSyncer
+}
+
+func newGatedSyncer() *gatedSyncer {
+	return &gatedSyncer{gate: make(chan struct{}), started: make(chan struct{})}
+}
+
+func (g *gatedSyncer) Write(p []byte) (int, error) {
+	g.once.Do(func() { close(g.started) })
+	<-g.gate
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.recordingSyncer.Write(p)
+}
+
+func (g *gatedSyncer) String() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.recordingSyncer.String()
+}
+
+// fillAsync writes "0" while the wrapped writer is stuck on it, then "1" to "4" into a queue of 2.
+func fillAsync(t *testing.T, policy OverflowPolicy) (*AsyncWriteSyncer, *gatedSyncer) {
+	g := newGatedSyncer()
+	a := NewAsyncWriteSyncer(g, 2, policy)
+
+	_, _ = a.Write([]byte("0"))
+	<-g.started
+	for _, entry := range []string{"1", "2", "3", "4"} {
+		if _, err := a.Write([]byte(entry)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return a, g
+}
+
+func TestAsyncWriteSyncerDropNewest(t *testing.T) {
+	a, g := fillAsync(t, OverflowDropNewest)
+	close(g.gate)
+
+	if err := a.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if g.String() != "012" || a.Dropped() != 2 || g.syncs != 1 {
+		t.Fatalf("unexpected output %q with %d dropped and %d syncs", g.String(), a.Dropped(), g.syncs)
+	}
+}
+
+func TestAsyncWriteSyncerDropOldest(t *testing.T) {
+	a, g := fillAsync(t, OverflowDropOldest)
+	close(g.gate)
+
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if g.String() != "034" || a.Dropped() != 2 {
+		t.Fatalf("unexpected output %q with %d dropped", g.String(), a.Dropped())
+	}
+	if _, err := a.Write([]byte("5")); err == nil {
+		t.Fatal("expected writes after Close to fail")
+	}
+}
+
+func TestAsyncWriteSyncerBlock(t *testing.T) {
+	g := newGatedSyncer()
+	a := NewAsyncWriteSyncer(g, 2, OverflowBlock)
+
+	_, _ = a.Write([]byte("0"))
+	<-g.started
+	_, _ = a.Write([]byte("1"))
+	_, _ = a.Write([]byte("2"))
+
+	written := make(chan struct{})
+	go func() {
+		_, _ = a.Write([]byte("3"))
+		close(written)
+	}()
+
+	close(g.gate)
+	<-written
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if g.String() != "0123" || a.Dropped() != 0 {
+		t.Fatalf("unexpected output %q with %d dropped", g.String(), a.Dropped())
+	}
+}
+
+func TestAsyncWriter(t *testing.T) {
+	ogWriter := Writer
+	defer func() { Writer = ogWriter }()
+
+	var b bytes.Buffer
+	a := NewAsyncWriteSyncer(traceSyncWrapper{&b}, 16, OverflowBlock)
+	Writer = a
+
+	for i := 0; i < 100; i++ {
+		Info("queued", Int("i", i))
+	}
+	if err := Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(b.String(), "\n"); n != 100 {
+		t.Fatalf("expected 100 lines after Sync, got %d", n)
+	}
+	_ = a.Close()
+}
+
+// exclusiveSyncer notes when Write and Sync are called at the same time.
+type exclusiveSyncer struct {
+	busy    int32
+	overlap int32
+}
+
+func (e *exclusiveSyncer) enter() {
+	if !atomic.CompareAndSwapInt32(&e.busy, 0, 1) {
+		atomic.StoreInt32(&e.overlap, 1)
+		return
+	}
+	time.Sleep(50 * time.Microsecond)
+	atomic.StoreInt32(&e.busy, 0)
+}
+
+func (e *exclusiveSyncer) Write(p []byte) (int, error) {
+	e.enter()
+	return len(p), nil
+}
+
+func (e *exclusiveSyncer) Sync() error {
+	e.enter()
+	return nil
+}
+
+func TestAsyncWriteSyncerSerializes(t *testing.T) {
+	e := &exclusiveSyncer{}
+	a := NewAsyncWriteSyncer(e, 64, OverflowBlock)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			_, _ = a.Write([]byte("x"))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			_ = a.Sync()
+		}
+	}()
+	wg.Wait()
+	_ = a.Close()
+
+	if atomic.LoadInt32(&e.overlap) != 0 {
+		t.Fatal("expected Write and Sync of the wrapped writer to never overlap")
+	}
+}
@@ -8,34 +8,112 @@ import (
 // bufPool represents a reusable buffer pool.
 var bufPool *bufferPool
 
-// bufferPool implements a pool of bytes.Buffers in the form of a bounded channel.
+// Buffer holds a single entry while an Encoder writes it, along with the objects and arrays currently open.
+type Buffer struct {
+	bytes.Buffer
+	scopes []Scope
+
+	// aux holds output an encoder wants to move to the end of the entry.
+	aux bytes.Buffer
+
+	// held holds fields an encoder wants to write once the entry ends.
+	held []Field
+
+	// truncated notes that part of the entry was cut because of the size limits.
+	truncated bool
+
+	// entry is the entry being logged, kept here so it is pooled along with the buffer.
+	entry Entry
+
+	// strictJSON has the JSONEncoder write strings and floats the way encoding/json reads them back, for the
+	// encoders built on it whose output is fed to strict parsers.
+	strictJSON bool
+}
+
+// Scope is an entry, object or array opened within a Buffer.
+type Scope struct {
+	// Key is the key the scope was opened with.
+	Key string
+
+	// Array is true for arrays, whose values have no keys.
+	Array bool
+
+	// Offset is where the scope starts in the buffer.
+	Offset int
+
+	// Count is the number of values the encoder has added to the scope.
+	Count int
+}
+
+// Push opens a new scope starting at the current end of the buffer.
+func (b *Buffer) Push(key string, array bool) {
+	b.scopes = append(b.scopes, Scope{Key: key, Array: array, Offset: b.Len()})
+}
+
+// Pop closes the innermost scope and returns it.
+func (b *Buffer) Pop() Scope {
+	s := b.scopes[len(b.scopes)-1]
+	b.scopes = b.scopes[:len(b.scopes)-1]
+	return s
+}
+
+// Top returns the innermost open scope. It is only valid until the next Push.
+func (b *Buffer) Top() *Scope {
+	return &b.scopes[len(b.scopes)-1]
+}
+
+// Scopes returns the open scopes, outermost first.
+func (b *Buffer) Scopes() []Scope {
+	return b.scopes
+}
+
+// Reset empties the buffer and closes every scope.
+func (b *Buffer) Reset() {
+	b.Buffer.Reset()
+	b.scopes = b.scopes[:0]
+	b.aux.Reset()
+	b.release()
+	b.truncated = false
+	b.strictJSON = false
+}
+
+// release empties held, letting go of the values it referenced.
+func (b *Buffer) release() {
+	for i := range b.held {
+		b.held[i] = Field{}
+	}
+	b.held = b.held[:0]
+}
+
+// bufferPool implements a pool of Buffers in the form of a bounded channel.
 type bufferPool struct {
-	c chan *bytes.Buffer
+	c chan *Buffer
 }
 
 // newBufferPool creates a new bufferPool bounded to the given size.
 func newBufferPool(size int) (bp *bufferPool) {
 	return &bufferPool{
-		c: make(chan *bytes.Buffer, size),
+		c: make(chan *Buffer, size),
 	}
 }
 
 // get gets a Buffer from the bufferPool, or creates a new one if none are
 // available in the pool.
-func (bp *bufferPool) get() (b *bytes.Buffer) {
+func (bp *bufferPool) get() (b *Buffer) {
 	select {
 	case b = <-bp.c:
 	// reuse existing buffer
 	default:
 		// create new buffer
-		b = bytes.NewBuffer([]byte{})
+		b = &Buffer{}
 	}
 	return
 }
 
 // put returns the given Buffer to the bufferPool.
-func (bp *bufferPool) put(b *bytes.Buffer) {
+func (bp *bufferPool) put(b *Buffer) {
 	b.Reset()
+	b.entry = Entry{}
 	select {
 	case bp.c <- b:
 	default: // Discard the buffer if the pool is full.
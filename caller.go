@@ -0,0 +1,78 @@
+package slog
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// callerDepth is the number of frames between runtime.Callers in callerField and the code calling a level func.
+const callerDepth = 4
+
+var callerCache sync.Map // map[uintptr]*callerInfo
+
+type callerInfo struct {
+	file     string
+	line     int
+	function string
+
+	short string
+	full  string
+}
+
+// callerField returns the caller field for the frame skip levels above callerField.
+func callerField(skip int) Field {
+	var pc [1]uintptr
+	if runtime.Callers(skip, pc[:]) < 1 {
+		return Skip()
+	}
+
+	return Field{key: CallerKey, fieldType: callerType, obj: lookupCaller(pc[0])}
+}
+
+func lookupCaller(pc uintptr) *callerInfo {
+	if ci, ok := callerCache.Load(pc); ok {
+		return ci.(*callerInfo)
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	actual, _ := callerCache.LoadOrStore(pc, newCallerInfo(frame.File, frame.Line, frame.Function))
+	return actual.(*callerInfo)
+}
+
+func newCallerInfo(file string, line int, function string) *callerInfo {
+	ci := &callerInfo{file: file, line: line, function: function}
+
+	l := strconv.Itoa(line)
+	ci.full = file + ":" + l + " " + function
+	ci.short = shortFile(file) + ":" + l + " " + shortFunction(function)
+	return ci
+}
+
+func (ci *callerInfo) String() string {
+	if CallerFullPath {
+		return ci.full
+	}
+	return ci.short
+}
+
+// shortFile trims the path down to the package directory and file name.
+func shortFile(file string) string {
+	i := strings.LastIndexByte(file, '/')
+	if i < 0 {
+		return file
+	}
+	if j := strings.LastIndexByte(file[:i], '/'); j >= 0 {
+		return file[j+1:]
+	}
+	return file
+}
+
+// shortFunction trims the import path from the function name, leaving "pkg.Func".
+func shortFunction(function string) string {
+	if i := strings.LastIndexByte(function, '/'); i >= 0 {
+		return function[i+1:]
+	}
+	return function
+}
@@ -0,0 +1,470 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	cborUint     = 0 << 5
+	cborNegInt   = 1 << 5
+	cborBytes    = 2 << 5
+	cborText     = 3 << 5
+	cborArray    = 4 << 5
+	cborMap      = 5 << 5
+	cborTag      = 6 << 5
+	cborSimple   = 7 << 5
+	cborFalse    = 0xf4
+	cborTrue     = 0xf5
+	cborNull     = 0xf6
+	cborFloat64  = 0xfb
+	cborBreak    = 0xff
+	cborIndefLen = 31
+)
+
+// CBOREncoder writes each entry as a CBOR (RFC 8949) map, which is cheaper to write and to parse than JSON. Objects
+// and arrays use indefinite lengths, so nothing has to be patched once it is written. Use CBORToJSON to read the
+// entries back.
+type CBOREncoder struct {
+	// Framed prefixes each entry with its length, see ReadFrame.
+	Framed bool
+}
+
+// NewCBOREncoder returns an encoder for framed CBOR output.
+func NewCBOREncoder() *CBOREncoder {
+	return &CBOREncoder{Framed: true}
+}
+
+func (c *CBOREncoder) cacheable() {}
+
+// BeginEntry implements Encoder.
+func (c *CBOREncoder) BeginEntry(b *Buffer, e *Entry) {
+	if c.Framed {
+		beginFrame(b)
+	}
+	b.WriteByte(cborMap | cborIndefLen)
+	b.Push("", false)
+
+	if !OmitLevel {
+		c.writeRawKey(b, SeverityKey)
+		if level, n, numeric := renderSeverity(e.Severity); numeric {
+			appendCBORInt(&b.Buffer, int64(n))
+		} else {
+			appendCBORText(&b.Buffer, level)
+		}
+	}
+
+	c.writeRawKey(b, TitleKey)
+	appendCBORText(&b.Buffer, e.Message)
+}
+
+// EndEntry implements Encoder.
+func (c *CBOREncoder) EndEntry(b *Buffer, e *Entry) {
+	if !OmitTime {
+		c.writeKey(b, TimeStampKey)
+		if len(TimeFormat) > 0 {
+			appendCBORText(&b.Buffer, e.Time.Format(TimeFormat))
+		} else {
+			appendCBORInt(&b.Buffer, e.Time.Unix())
+		}
+	}
+
+	s := b.Pop()
+	b.WriteByte(cborBreak)
+	if c.Framed {
+		endFrame(b, s.Offset-1-frameHeaderSize)
+	}
+}
+
+// AddBool implements Encoder.
+func (c *CBOREncoder) AddBool(b *Buffer, key string, val bool) {
+	c.writeKey(b, key)
+	if val {
+		b.WriteByte(cborTrue)
+	} else {
+		b.WriteByte(cborFalse)
+	}
+}
+
+// AddInt64 implements Encoder.
+func (c *CBOREncoder) AddInt64(b *Buffer, key string, val int64) {
+	c.writeKey(b, key)
+	appendCBORInt(&b.Buffer, val)
+}
+
+// AddUint64 implements Encoder.
+func (c *CBOREncoder) AddUint64(b *Buffer, key string, val uint64) {
+	c.writeKey(b, key)
+	appendCBORHead(&b.Buffer, cborUint, val)
+}
+
+// AddFloat64 implements Encoder.
+func (c *CBOREncoder) AddFloat64(b *Buffer, key string, val float64) {
+	c.writeKey(b, key)
+	b.WriteByte(cborFloat64)
+	var bits [8]byte
+	binary.BigEndian.PutUint64(bits[:], math.Float64bits(val))
+	b.Write(bits[:])
+}
+
+// AddString implements Encoder.
+func (c *CBOREncoder) AddString(b *Buffer, key, val string) {
+	c.writeKey(b, key)
+	appendCBORText(&b.Buffer, val)
+}
+
+// AddJSONString implements Encoder.
+func (c *CBOREncoder) AddJSONString(b *Buffer, key, val string) {
+	c.AddString(b, key, val)
+}
+
+// AddRawJSON implements Encoder, converting the value into CBOR.
+func (c *CBOREncoder) AddRawJSON(b *Buffer, key string, val []byte) {
+	encodeJSONValue(c, b, key, val)
+}
+
+// AddNull implements Encoder.
+func (c *CBOREncoder) AddNull(b *Buffer, key string) {
+	c.writeKey(b, key)
+	b.WriteByte(cborNull)
+}
+
+// BeginObject implements Encoder.
+func (c *CBOREncoder) BeginObject(b *Buffer, key string) {
+	c.writeKey(b, key)
+	b.WriteByte(cborMap | cborIndefLen)
+	b.Push(key, false)
+}
+
+// EndObject implements Encoder.
+func (c *CBOREncoder) EndObject(b *Buffer) {
+	b.Pop()
+	b.WriteByte(cborBreak)
+}
+
+// BeginArray implements Encoder.
+func (c *CBOREncoder) BeginArray(b *Buffer, key string) {
+	c.writeKey(b, key)
+	b.WriteByte(cborArray | cborIndefLen)
+	b.Push(key, true)
+}
+
+// EndArray implements Encoder.
+func (c *CBOREncoder) EndArray(b *Buffer) {
+	b.Pop()
+	b.WriteByte(cborBreak)
+}
+
+// writeKey writes the key for the next value, leaving it out inside arrays.
+func (c *CBOREncoder) writeKey(b *Buffer, key string) {
+	top := b.Top()
+	top.Count++
+	if !top.Array {
+		appendCBORText(&b.Buffer, key)
+	}
+}
+
+// writeRawKey is writeKey for the built in keys.
+func (c *CBOREncoder) writeRawKey(b *Buffer, key []byte) {
+	b.Top().Count++
+	appendCBORHead(&b.Buffer, cborText, uint64(len(key)))
+	b.Write(key)
+}
+
+// appendCBORHead writes the initial byte of an item along with its argument, in as few bytes as possible.
+func appendCBORHead(b *bytes.Buffer, major byte, n uint64) {
+	switch {
+	case n < 24:
+		b.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		b.WriteByte(major | 24)
+		b.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		b.WriteByte(major | 25)
+		b.WriteByte(byte(n >> 8))
+		b.WriteByte(byte(n))
+	case n <= math.MaxUint32:
+		b.WriteByte(major | 26)
+		var arg [4]byte
+		binary.BigEndian.PutUint32(arg[:], uint32(n))
+		b.Write(arg[:])
+	default:
+		b.WriteByte(major | 27)
+		var arg [8]byte
+		binary.BigEndian.PutUint64(arg[:], n)
+		b.Write(arg[:])
+	}
+}
+
+func appendCBORInt(b *bytes.Buffer, val int64) {
+	if val >= 0 {
+		appendCBORHead(b, cborUint, uint64(val))
+	} else {
+		appendCBORHead(b, cborNegInt, uint64(-1-val))
+	}
+}
+
+// appendCBORText writes a text string, which has to be valid UTF-8.
+func appendCBORText(b *bytes.Buffer, val string) {
+	if !utf8.ValidString(val) {
+		val = strings.ToValidUTF8(val, "\ufffd")
+	}
+	appendCBORHead(b, cborText, uint64(len(val)))
+	b.WriteString(val)
+}
+
+// CBORToJSON converts a single CBOR item, such as an entry written by CBOREncoder, into JSON. Map keys keep their
+// order, byte strings become base64 strings and tags are dropped.
+func CBORToJSON(data []byte) ([]byte, error) {
+	d := &cborDecoder{data: data}
+	var out bytes.Buffer
+	if err := d.value(&out, 0); err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("slog: %d bytes of trailing data after CBOR item", len(d.data)-d.pos)
+	}
+	return out.Bytes(), nil
+}
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *cborDecoder) value(out *bytes.Buffer, depth int) error {
+	if depth > maxDecodeDepth {
+		return errors.New("slog: CBOR item is nested too deeply")
+	}
+
+	initial, err := d.byte()
+	if err != nil {
+		return err
+	}
+	major, info := initial&0xe0, initial&0x1f
+
+	switch major {
+	case cborUint:
+		n, err := d.argument(info)
+		if err != nil {
+			return err
+		}
+		appendUint64Value(out, n)
+	case cborNegInt:
+		n, err := d.argument(info)
+		if err != nil {
+			return err
+		}
+		if n <= math.MaxInt64 {
+			appendInt64Value(out, -1-int64(n))
+		} else {
+			v := new(big.Int).SetUint64(n)
+			out.WriteString(v.Add(v, big.NewInt(1)).Neg(v).String())
+		}
+	case cborBytes:
+		s, err := d.string(major, info)
+		if err != nil {
+			return err
+		}
+		out.WriteByte('"')
+		out.WriteString(base64.StdEncoding.EncodeToString(s))
+		out.WriteByte('"')
+	case cborText:
+		s, err := d.string(major, info)
+		if err != nil {
+			return err
+		}
+		appendDecodedString(out, s)
+	case cborArray:
+		return d.container(out, info, depth, false)
+	case cborMap:
+		return d.container(out, info, depth, true)
+	case cborTag:
+		if _, err := d.argument(info); err != nil {
+			return err
+		}
+		return d.value(out, depth+1)
+	case cborSimple:
+		return d.simple(out, info)
+	}
+	return nil
+}
+
+// container writes an array or a map, of either a definite or an indefinite length.
+func (d *cborDecoder) container(out *bytes.Buffer, info byte, depth int, isMap bool) error {
+	n, indefinite := uint64(0), info == cborIndefLen
+	if !indefinite {
+		var err error
+		if n, err = d.argument(info); err != nil {
+			return err
+		}
+		if n > uint64(len(d.data)-d.pos) {
+			return errTruncatedInput
+		}
+	}
+
+	open, end := byte('['), byte(']')
+	if isMap {
+		open, end = '{', '}'
+	}
+
+	out.WriteByte(open)
+	for i := uint64(0); indefinite || i < n; i++ {
+		if indefinite {
+			if d.pos >= len(d.data) {
+				return errTruncatedInput
+			}
+			if d.data[d.pos] == cborBreak {
+				d.pos++
+				break
+			}
+		}
+		if i > 0 {
+			out.WriteByte(',')
+		}
+
+		if isMap {
+			var key bytes.Buffer
+			if err := d.value(&key, depth+1); err != nil {
+				return err
+			}
+			appendDecodedKey(out, key.Bytes())
+		}
+		if err := d.value(out, depth+1); err != nil {
+			return err
+		}
+	}
+	out.WriteByte(end)
+	return nil
+}
+
+func (d *cborDecoder) simple(out *bytes.Buffer, info byte) error {
+	switch info {
+	case 20:
+		out.WriteString("false")
+	case 21:
+		out.WriteString("true")
+	case 22, 23:
+		out.WriteString("null")
+	case 25:
+		bits, err := d.next(2)
+		if err != nil {
+			return err
+		}
+		appendStrictFloatValue(out, halfToFloat64(binary.BigEndian.Uint16(bits)))
+	case 26:
+		bits, err := d.next(4)
+		if err != nil {
+			return err
+		}
+		appendStrictFloatValue(out, float64(math.Float32frombits(binary.BigEndian.Uint32(bits))))
+	case 27:
+		bits, err := d.next(8)
+		if err != nil {
+			return err
+		}
+		appendStrictFloatValue(out, math.Float64frombits(binary.BigEndian.Uint64(bits)))
+	default:
+		return fmt.Errorf("slog: unsupported CBOR simple value %d", info)
+	}
+	return nil
+}
+
+// string reads a byte or text string, joining the chunks of an indefinite length string.
+func (d *cborDecoder) string(major, info byte) ([]byte, error) {
+	if info != cborIndefLen {
+		n, err := d.argument(info)
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(d.data)-d.pos) {
+			return nil, errTruncatedInput
+		}
+		return d.next(int(n))
+	}
+
+	var s []byte
+	for {
+		initial, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		if initial == cborBreak {
+			return s, nil
+		}
+		if initial&0xe0 != major || initial&0x1f == cborIndefLen {
+			return nil, errors.New("slog: invalid chunk in CBOR string")
+		}
+
+		chunk, err := d.string(major, initial&0x1f)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, chunk...)
+	}
+}
+
+func (d *cborDecoder) argument(info byte) (uint64, error) {
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info <= 27:
+		arg, err := d.next(1 << (info - 24))
+		if err != nil {
+			return 0, err
+		}
+		var n uint64
+		for _, c := range arg {
+			n = n<<8 | uint64(c)
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("slog: invalid CBOR argument %d", info)
+}
+
+func (d *cborDecoder) byte() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, errTruncatedInput
+	}
+	d.pos++
+	return d.data[d.pos-1], nil
+}
+
+func (d *cborDecoder) next(n int) ([]byte, error) {
+	if n > len(d.data)-d.pos {
+		return nil, errTruncatedInput
+	}
+	d.pos += n
+	return d.data[d.pos-n : d.pos], nil
+}
+
+// halfToFloat64 converts an IEEE 754 half precision float.
+func halfToFloat64(h uint16) float64 {
+	exp, frac := int(h>>10&0x1f), float64(h&0x3ff)
+
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(frac, -24)
+	case 0x1f:
+		if frac == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(frac+1024, exp-25)
+	}
+
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
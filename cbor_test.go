@@ -0,0 +1,92 @@
+package slog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// binaryEntries logs a few entries with enc and returns them converted back into JSON.
+func binaryEntries(t *testing.T, enc Encoder, toJSON func([]byte) ([]byte, error)) []string {
+	ogWriter, ogEncoder := Writer, DefaultEncoder
+	defer func() {
+		Writer, DefaultEncoder = ogWriter, ogEncoder
+		globalMu.Lock()
+		storeGlobals(nil)
+		globalMu.Unlock()
+	}()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	DefaultEncoder = enc
+	AddGlobalFields(String("app", "api"))
+
+	Info("first", Int("n", -1000), Uint64("u", 1<<40), Float64("f", 0.5), Bool("ok", true),
+		String("s", `a "quote"`), RawJSON("raw", []byte(`{"a":[1,-2.5,null,"x"]}`)),
+		Struct("st", struct {
+			Tags []string `slog:"tags"`
+		}{Tags: []string{"a"}}))
+	Warning("second")
+
+	var entries []string
+	for {
+		frame, err := ReadFrame(&b)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := toJSON(frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, string(out))
+	}
+	return entries
+}
+
+func TestCBOREncoder(t *testing.T) {
+	entries := binaryEntries(t, NewCBOREncoder(), CBORToJSON)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	expected := `{"level":"info","msg":"first","n":-1000,"u":1099511627776,"f":0.5,"ok":true,"s":"a \"quote\"",` +
+		`"raw":{"a":[1,-2.5,null,"x"]},"st":{"tags":["a"]},"app":"api","ts":`
+	if !strings.HasPrefix(entries[0], expected) {
+		t.Fatalf("unexpected entry:\n%s\nexpected prefix:\n%s", entries[0], expected)
+	}
+	if !strings.HasPrefix(entries[1], `{"level":"warn","msg":"second","app":"api","ts":`) {
+		t.Fatalf("unexpected entry: %s", entries[1])
+	}
+}
+
+func TestCBORToJSON(t *testing.T) {
+	tests := map[string]string{
+		"\x83\x01\x20\xf9\x3e\x00":             `[1,-1,1.5]`,
+		"\xa1\x01\x44\x01\x02\x03\x04":         `{"1":"AQIDBA=="}`,
+		"\xc1\x1a\x51\x4b\x67\xb0":             `1363896240`,
+		"\x7f\x62\x61\x62\x61\x63\xff":         `"abc"`,
+		"\x3b\xff\xff\xff\xff\xff\xff\xff\xff": `-18446744073709551616`,
+		"\x82\xf9\x7c\x00\xf9\x7e\x00":         `["+Inf","NaN"]`,
+	}
+	for in, expected := range tests {
+		out, err := CBORToJSON([]byte(in))
+		if err != nil {
+			t.Fatalf("%x: %v", in, err)
+		}
+		if string(out) != expected {
+			t.Fatalf("%x: expected %s, got %s", in, expected, out)
+		}
+	}
+
+	for _, in := range []string{"", "\x82\x01", "\x9f\x01", "\x01\x02", strings.Repeat("\x81", 1000) + "\x01"} {
+		if _, err := CBORToJSON([]byte(in)); err == nil {
+			t.Fatalf("%x: expected an error", in)
+		}
+	}
+}
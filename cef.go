@@ -0,0 +1,217 @@
+package slog
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// CEFEncoder writes each entry as an ArcSight Common Event Format event, such as
+// `CEF:0|Acme|Billing|1.0|user login|user login|3|rt=1700000000000 msg=user login suser=bob`. The message is used as
+// both the signature ID and the name, and fields become extensions. Fields named in Extensions are written under
+// their standard extension names, while the rest keep their keys with anything but letters and digits removed.
+// Add it as the encoder of a syslog sink to forward events to a SIEM.
+type CEFEncoder struct {
+	Vendor  string
+	Product string
+	Version string
+
+	// Extensions maps field keys onto standard extension names, such as "client_ip" to "src".
+	Extensions map[string]string
+}
+
+// NewCEFEncoder returns a CEF encoder for the device, mapping the fields in DefaultCEFExtensions.
+func NewCEFEncoder(vendor, product, version string) *CEFEncoder {
+	return &CEFEncoder{Vendor: vendor, Product: product, Version: version, Extensions: DefaultCEFExtensions()}
+}
+
+// DefaultCEFExtensions returns the field keys mapped onto standard CEF extension names by default.
+func DefaultCEFExtensions() map[string]string {
+	return map[string]string{
+		"src_ip":  "src",
+		"dst_ip":  "dst",
+		"user":    "suser",
+		"action":  "act",
+		"outcome": "outcome",
+	}
+}
+
+func (c *CEFEncoder) cacheable() {}
+
+// BeginEntry implements Encoder.
+func (c *CEFEncoder) BeginEntry(b *Buffer, e *Entry) {
+	b.Push("", false)
+
+	b.WriteString("CEF:0|")
+	for _, val := range []string{c.Vendor, c.Product, c.Version, e.Message, e.Message} {
+		appendCEFHeader(&b.Buffer, val)
+		b.WriteByte('|')
+	}
+	appendInt64Value(&b.Buffer, int64(siemSeverity(e.Severity, 0)))
+	b.WriteByte('|')
+
+	b.WriteString("rt=")
+	appendInt64Value(&b.Buffer, e.Time.UnixNano()/1e6)
+	b.WriteString(" msg=")
+	appendCEFValue(&b.Buffer, e.Message)
+	b.Top().Count++
+}
+
+// EndEntry implements Encoder.
+func (c *CEFEncoder) EndEntry(b *Buffer, e *Entry) {
+	b.Pop()
+	b.WriteByte('\n')
+}
+
+// AddBool implements Encoder.
+func (c *CEFEncoder) AddBool(b *Buffer, key string, val bool) {
+	c.AddString(b, key, strconv.FormatBool(val))
+}
+
+// AddInt64 implements Encoder.
+func (c *CEFEncoder) AddInt64(b *Buffer, key string, val int64) {
+	c.writeKey(b, key)
+	appendInt64Value(&b.Buffer, val)
+}
+
+// AddUint64 implements Encoder.
+func (c *CEFEncoder) AddUint64(b *Buffer, key string, val uint64) {
+	c.writeKey(b, key)
+	appendUint64Value(&b.Buffer, val)
+}
+
+// AddFloat64 implements Encoder.
+func (c *CEFEncoder) AddFloat64(b *Buffer, key string, val float64) {
+	c.writeKey(b, key)
+	appendFloatValue(&b.Buffer, val)
+}
+
+// AddString implements Encoder.
+func (c *CEFEncoder) AddString(b *Buffer, key, val string) {
+	c.writeKey(b, key)
+	appendCEFValue(&b.Buffer, val)
+}
+
+// AddJSONString implements Encoder.
+func (c *CEFEncoder) AddJSONString(b *Buffer, key, val string) {
+	c.AddString(b, key, val)
+}
+
+// AddRawJSON implements Encoder, flattening the value like any other nested value.
+func (c *CEFEncoder) AddRawJSON(b *Buffer, key string, val []byte) {
+	encodeJSONValue(c, b, key, val)
+}
+
+// AddNull implements Encoder. The field is left out.
+func (c *CEFEncoder) AddNull(b *Buffer, key string) {
+	b.Top().Count++
+}
+
+// BeginObject implements Encoder.
+func (c *CEFEncoder) BeginObject(b *Buffer, key string) {
+	b.Top().Count++
+	b.Push(key, false)
+}
+
+// EndObject implements Encoder.
+func (c *CEFEncoder) EndObject(b *Buffer) {
+	b.Pop()
+}
+
+// BeginArray implements Encoder.
+func (c *CEFEncoder) BeginArray(b *Buffer, key string) {
+	b.Top().Count++
+	b.Push(key, true)
+}
+
+// EndArray implements Encoder.
+func (c *CEFEncoder) EndArray(b *Buffer) {
+	b.Pop()
+}
+
+func (c *CEFEncoder) writeKey(b *Buffer, key string) {
+	b.WriteByte(' ')
+	writeSIEMKey(b, c.Extensions, key)
+	b.WriteByte('=')
+}
+
+// writeSIEMKey writes the key of the next value, using the mapped name for top level keys. Other keys are
+// flattened like the logfmt output, keeping only letters and digits.
+func writeSIEMKey(b *Buffer, mapping map[string]string, key string) {
+	scopes := b.Scopes()
+	if len(scopes) == 1 {
+		if name, ok := mapping[key]; ok {
+			b.WriteString(name)
+			b.Top().Count++
+			return
+		}
+	}
+
+	start := b.Len()
+	writeDottedKey(&b.Buffer, scopes, key)
+	b.Top().Count++
+
+	written := b.Bytes()[start:]
+	n := 0
+	for _, c := range written {
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+			written[n] = c
+			n++
+		}
+	}
+	if n == 0 {
+		written[0] = '_'
+		n = 1
+	}
+	b.Truncate(start + n)
+}
+
+// siemSeverity maps a severity onto the 0 to 10 scale of CEF and LEEF, starting debug at lowest.
+func siemSeverity(severity string, lowest int) int {
+	switch severity {
+	case SeverityDebug:
+		return lowest
+	case SeverityInfo:
+		return 3
+	case SeverityWarn:
+		return 5
+	case SeverityError:
+		return 7
+	case SeverityPanic:
+		return 9
+	case SeverityFatal:
+		return 10
+	}
+	return 5
+}
+
+// appendCEFHeader escapes pipes and backslashes in a header value, and replaces line breaks with spaces.
+func appendCEFHeader(b *bytes.Buffer, val string) {
+	for i := 0; i < len(val); i++ {
+		switch c := val[i]; c {
+		case '\\', '|':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\r', '\n':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// appendCEFValue escapes equal signs, backslashes and line breaks in an extension value.
+func appendCEFValue(b *bytes.Buffer, val string) {
+	for i := 0; i < len(val); i++ {
+		switch c := val[i]; c {
+		case '\\', '=':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package slog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCEFEncoder(t *testing.T) {
+	ogWriter, ogEncoder := Writer, DefaultEncoder
+	defer func() { Writer, DefaultEncoder = ogWriter, ogEncoder }()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	DefaultEncoder = NewCEFEncoder("Acme|Corp", "Billing", "1.0")
+
+	Error("refund denied", String("user", "bob"), String("src_ip", "10.0.0.1"), String("action", "a=b\\c"),
+		String("note", "two\nlines"), Bool("ok", false), Struct("order", struct {
+			ID   int      `slog:"id"`
+			Tags []string `slog:"tags"`
+		}{ID: 7, Tags: []string{"a", "b"}}), RawJSON("raw", []byte(`[1,{"b":2}]`)))
+
+	line := b.String()
+	if !strings.HasPrefix(line, `CEF:0|Acme\|Corp|Billing|1.0|refund denied|refund denied|7|rt=`) {
+		t.Fatalf("unexpected header: %s", line)
+	}
+
+	expected := ` msg=refund denied suser=bob src=10.0.0.1 act=a\=b\\c note=two\nlines ok=false orderid=7 ordertags0=a ordertags1=b raw0=1 raw1b=2` + "\n"
+	if !strings.HasSuffix(line, expected) {
+		t.Fatalf("unexpected extensions:\n%s\nexpected suffix:\n%s", line, expected)
+	}
+}
+
+func TestLEEFEncoder(t *testing.T) {
+	ogWriter, ogEncoder := Writer, DefaultEncoder
+	defer func() { Writer, DefaultEncoder = ogWriter, ogEncoder }()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	DefaultEncoder = NewLEEFEncoder("Acme", "Billing", "1.0")
+
+	Warning("login failed", String("user", "bob"), String("dst_ip", "10.0.0.2"), String("reason", "bad\tpassword"),
+		RawJSON("ips", []byte(`["10.0.0.3",{"v6":"::1"}]`)))
+
+	line := b.String()
+	if !strings.HasPrefix(line, "LEEF:1.0|Acme|Billing|1.0|login failed|devTime=") {
+		t.Fatalf("unexpected header: %s", line)
+	}
+
+	expected := "\tsev=5\tmsg=login failed\tusrName=bob\tdst=10.0.0.2\treason=bad\\tpassword\tips0=10.0.0.3\tips1v6=::1\n"
+	if !strings.HasSuffix(line, expected) {
+		t.Fatalf("unexpected attributes: %q", line)
+	}
+}
@@ -0,0 +1,151 @@
+package slog
+
+import (
+	"strings"
+)
+
+// LevelFormatType is a way of rendering the severity.
+type LevelFormatType int
+
+const (
+	// LevelLower renders severities as is, such as "info".
+	LevelLower LevelFormatType = iota
+
+	// LevelUpper renders severities in upper case, such as "INFO".
+	LevelUpper
+
+	// LevelCapital renders severities capitalized, such as "Info".
+	LevelCapital
+
+	// LevelLetter renders severities as their upper case first letter, such as "I".
+	LevelLetter
+
+	// LevelNumeric renders severities as numbers, from 10 for debug up to 60 for fatal. Custom severities are 0.
+	LevelNumeric
+)
+
+// EncoderConfig gathers the keys of the built in values and fields, along with how the severity is rendered. It
+// is a single place to set the package variables of the same names, which stay in use.
+type EncoderConfig struct {
+	TimeKey      string
+	LevelKey     string
+	MessageKey   string
+	ErrorKey     string
+	CallerKey    string
+	StackKey     string
+	RequestIDKey string
+	TraceIDKey   string
+	SpanIDKey    string
+
+	// TimeFormat formats the timestamp, which is written as Unix seconds when empty.
+	TimeFormat string
+
+	LevelFormat LevelFormatType
+	OmitTime    bool
+	OmitLevel   bool
+}
+
+// DefaultEncoderConfig returns the configuration the package starts with.
+func DefaultEncoderConfig() EncoderConfig {
+	return EncoderConfig{
+		TimeKey:      "ts",
+		LevelKey:     "level",
+		MessageKey:   "msg",
+		ErrorKey:     "err",
+		CallerKey:    "caller",
+		StackKey:     "stack",
+		RequestIDKey: "reqID",
+		TraceIDKey:   "trace_id",
+		SpanIDKey:    "span_id",
+	}
+}
+
+// CurrentEncoderConfig returns the configuration currently in use.
+func CurrentEncoderConfig() EncoderConfig {
+	return EncoderConfig{
+		TimeKey:      TimeStampKey,
+		LevelKey:     string(SeverityKey),
+		MessageKey:   string(TitleKey),
+		ErrorKey:     ErrorKey,
+		CallerKey:    CallerKey,
+		StackKey:     StackKey,
+		RequestIDKey: RequestFieldKey,
+		TraceIDKey:   TraceIDKey,
+		SpanIDKey:    SpanIDKey,
+		TimeFormat:   TimeFormat,
+		LevelFormat:  LevelFormat,
+		OmitTime:     OmitTime,
+		OmitLevel:    OmitLevel,
+	}
+}
+
+// SetEncoderConfig applies the configuration. Like the variables it sets, it should be called before logging
+// starts. Empty keys keep their current value.
+func SetEncoderConfig(cfg EncoderConfig) {
+	setKey(&TimeStampKey, cfg.TimeKey)
+	if cfg.LevelKey != "" {
+		SeverityKey = []byte(cfg.LevelKey)
+	}
+	if cfg.MessageKey != "" {
+		TitleKey = []byte(cfg.MessageKey)
+	}
+	setKey(&ErrorKey, cfg.ErrorKey)
+	setKey(&CallerKey, cfg.CallerKey)
+	setKey(&StackKey, cfg.StackKey)
+	setKey(&RequestFieldKey, cfg.RequestIDKey)
+	setKey(&TraceIDKey, cfg.TraceIDKey)
+	setKey(&SpanIDKey, cfg.SpanIDKey)
+
+	TimeFormat = cfg.TimeFormat
+	LevelFormat = cfg.LevelFormat
+	OmitTime = cfg.OmitTime
+	OmitLevel = cfg.OmitLevel
+}
+
+func setKey(key *string, val string) {
+	if val != "" {
+		*key = val
+	}
+}
+
+// renderSeverity renders the severity as set by LevelFormat, returning the number for LevelNumeric.
+func renderSeverity(severity string) (string, int, bool) {
+	switch LevelFormat {
+	case LevelUpper:
+		upper, _ := severityForms(severity)
+		return upper, 0, false
+	case LevelCapital:
+		_, capital := severityForms(severity)
+		return capital, 0, false
+	case LevelLetter:
+		upper, _ := severityForms(severity)
+		if len(upper) > 1 {
+			upper = upper[:1]
+		}
+		return upper, 0, false
+	case LevelNumeric:
+		return "", (severityRank(severity) + 1) * 10, true
+	}
+	return severity, 0, false
+}
+
+// severityForms returns the severity in upper case and capitalized, without allocating for the built in ones.
+func severityForms(severity string) (string, string) {
+	switch severity {
+	case SeverityDebug:
+		return "DEBUG", "Debug"
+	case SeverityInfo:
+		return "INFO", "Info"
+	case SeverityWarn:
+		return "WARN", "Warn"
+	case SeverityError:
+		return "ERROR", "Error"
+	case SeverityPanic:
+		return "PANIC", "Panic"
+	case SeverityFatal:
+		return "FATAL", "Fatal"
+	case "":
+		return "", ""
+	}
+	return strings.ToUpper(severity), strings.ToUpper(severity[:1]) + severity[1:]
+}
@@ -0,0 +1,62 @@
+package slog
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEncoderConfig(t *testing.T) {
+	ogWriter, ogConfig := Writer, CurrentEncoderConfig()
+	defer func() {
+		Writer = ogWriter
+		SetEncoderConfig(ogConfig)
+	}()
+
+	if ogConfig != DefaultEncoderConfig() {
+		t.Fatalf("expected the default config, got %+v", ogConfig)
+	}
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+
+	cfg := DefaultEncoderConfig()
+	cfg.LevelKey = "severity"
+	cfg.MessageKey = "message"
+	cfg.ErrorKey = "error"
+	cfg.LevelFormat = LevelUpper
+	cfg.OmitTime = true
+	SetEncoderConfig(cfg)
+
+	Warning("disk low", Err(errors.New("full")))
+	if expected := `{"severity":"WARN", "message":"disk low", "error":"full"}` + "\n"; b.String() != expected {
+		t.Fatalf("unexpected output: %s", b.String())
+	}
+
+	tests := map[LevelFormatType]string{
+		LevelLower:   `{"level":"warn", "msg":"x"}`,
+		LevelCapital: `{"level":"Warn", "msg":"x"}`,
+		LevelLetter:  `{"level":"W", "msg":"x"}`,
+		LevelNumeric: `{"level":30, "msg":"x"}`,
+	}
+	for format, expected := range tests {
+		cfg = DefaultEncoderConfig()
+		cfg.LevelFormat = format
+		cfg.OmitTime = true
+		SetEncoderConfig(cfg)
+
+		b.Reset()
+		Warning("x")
+		if b.String() != expected+"\n" {
+			t.Fatalf("format %d: unexpected output: %s", format, b.String())
+		}
+	}
+
+	cfg.OmitLevel = true
+	SetEncoderConfig(cfg)
+	b.Reset()
+	Warning("x")
+	if b.String() != `{"msg":"x"}`+"\n" {
+		t.Fatalf("unexpected output: %s", b.String())
+	}
+}
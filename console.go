@@ -0,0 +1,275 @@
+package slog
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	colorReset   = "\x1b[0m"
+	colorBold    = "\x1b[1m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+	colorGray    = "\x1b[90m"
+)
+
+// ConsoleEncoder writes human friendly lines for local development, such as
+// `15:04:05.000 INF user login                      user=42 reqID=abc`. Nested values are flattened like the
+// logfmt output, and stacks are printed on their own lines after the entry.
+type ConsoleEncoder struct {
+	// Color adds ANSI colors to the output.
+	Color bool
+
+	// TimeLayout formats the time at the start of the line. Defaults to "15:04:05.000".
+	TimeLayout string
+
+	// MessageWidth pads messages so the fields line up.
+	MessageWidth int
+}
+
+// NewConsoleEncoder returns a console encoder, optionally with colors.
+func NewConsoleEncoder(color bool) *ConsoleEncoder {
+	return &ConsoleEncoder{Color: color, TimeLayout: "15:04:05.000", MessageWidth: 32}
+}
+
+// AutoEncoder returns a colored ConsoleEncoder when f is a terminal, and a JSONEncoder otherwise. Colors are left
+// off when the NO_COLOR environment variable is set. It is opt in, the `DefaultEncoder` stays JSON so the output
+// of existing programs does not change with where it is viewed:
+//
+//	slog.DefaultEncoder = slog.AutoEncoder(os.Stdout)
+func AutoEncoder(f *os.File) Encoder {
+	if !isTerminal(f) {
+		return NewJSONEncoder()
+	}
+	return NewConsoleEncoder(os.Getenv("NO_COLOR") == "")
+}
+
+func (c *ConsoleEncoder) cacheable() {}
+
+// BeginEntry implements Encoder.
+func (c *ConsoleEncoder) BeginEntry(b *Buffer, e *Entry) {
+	b.Push("", false)
+
+	layout := c.TimeLayout
+	if layout == "" {
+		layout = "15:04:05.000"
+	}
+	c.colored(b, colorGray, e.Time.Format(layout))
+	b.WriteByte(' ')
+
+	c.colored(b, severityColor(e.Severity), severityAbbreviation(e.Severity))
+	b.WriteByte(' ')
+
+	b.WriteString(e.Message)
+	for n := utf8.RuneCountInString(e.Message); n < c.MessageWidth; n++ {
+		b.WriteByte(' ')
+	}
+}
+
+// EndEntry implements Encoder, writing any stack below the line.
+func (c *ConsoleEncoder) EndEntry(b *Buffer, e *Entry) {
+	if b.Pop().Count == 0 {
+		// Nothing followed the message, so drop its padding.
+		for b.Len() > 0 && b.Bytes()[b.Len()-1] == ' ' {
+			b.Truncate(b.Len() - 1)
+		}
+	}
+
+	_, _ = b.aux.WriteTo(&b.Buffer)
+	b.WriteByte('\n')
+}
+
+// AddBool implements Encoder.
+func (c *ConsoleEncoder) AddBool(b *Buffer, key string, val bool) {
+	if c.writeKey(b, key) {
+		return
+	}
+	if val {
+		b.WriteString("true")
+	} else {
+		b.WriteString("false")
+	}
+}
+
+// AddInt64 implements Encoder.
+func (c *ConsoleEncoder) AddInt64(b *Buffer, key string, val int64) {
+	if c.inStack(b) {
+		// The line number of a stack frame.
+		b.aux.WriteByte(':')
+		appendInt64Value(&b.aux, val)
+		return
+	}
+
+	c.writeKey(b, key)
+	appendInt64Value(&b.Buffer, val)
+}
+
+// AddUint64 implements Encoder.
+func (c *ConsoleEncoder) AddUint64(b *Buffer, key string, val uint64) {
+	if c.writeKey(b, key) {
+		return
+	}
+	appendUint64Value(&b.Buffer, val)
+}
+
+// AddFloat64 implements Encoder.
+func (c *ConsoleEncoder) AddFloat64(b *Buffer, key string, val float64) {
+	if c.writeKey(b, key) {
+		return
+	}
+	appendFloatValue(&b.Buffer, val)
+}
+
+// AddString implements Encoder.
+func (c *ConsoleEncoder) AddString(b *Buffer, key, val string) {
+	if c.inStack(b) {
+		// Frames are written as the function, with the file and line indented below it.
+		if key == "file" {
+			b.aux.WriteString("\n        ")
+		}
+		b.aux.WriteString(val)
+		return
+	}
+
+	c.writeKey(b, key)
+	if c.Color && key == ErrorKey {
+		b.WriteString(colorRed)
+		appendLogfmtValue(&b.Buffer, val)
+		b.WriteString(colorReset)
+		return
+	}
+	appendLogfmtValue(&b.Buffer, val)
+}
+
+// AddJSONString implements Encoder.
+func (c *ConsoleEncoder) AddJSONString(b *Buffer, key, val string) {
+	c.AddString(b, key, val)
+}
+
+// AddRawJSON implements Encoder, compacting the value onto the line.
+func (c *ConsoleEncoder) AddRawJSON(b *Buffer, key string, val []byte) {
+	if c.writeKey(b, key) {
+		return
+	}
+
+	mark := b.Len()
+	if err := json.Compact(&b.Buffer, val); err != nil {
+		b.Truncate(mark)
+		appendLogfmtQuoted(&b.Buffer, val)
+	}
+}
+
+// AddNull implements Encoder.
+func (c *ConsoleEncoder) AddNull(b *Buffer, key string) {
+	if c.writeKey(b, key) {
+		return
+	}
+	b.WriteString("null")
+}
+
+// BeginObject implements Encoder.
+func (c *ConsoleEncoder) BeginObject(b *Buffer, key string) {
+	if c.inStack(b) {
+		b.aux.WriteString("\n    ")
+	}
+	b.Top().Count++
+	b.Push(key, false)
+}
+
+// EndObject implements Encoder.
+func (c *ConsoleEncoder) EndObject(b *Buffer) {
+	b.Pop()
+}
+
+// BeginArray implements Encoder.
+func (c *ConsoleEncoder) BeginArray(b *Buffer, key string) {
+	b.Top().Count++
+	b.Push(key, true)
+}
+
+// EndArray implements Encoder.
+func (c *ConsoleEncoder) EndArray(b *Buffer) {
+	b.Pop()
+}
+
+// writeKey writes the separator and the dotted key for the next value. Values that belong to a stack are not
+// written on the line, in which case it returns true.
+func (c *ConsoleEncoder) writeKey(b *Buffer, key string) bool {
+	if c.inStack(b) {
+		return true
+	}
+
+	b.WriteByte(' ')
+	if c.Color {
+		b.WriteString(colorCyan)
+	}
+	writeDottedKey(&b.Buffer, b.Scopes(), key)
+	b.WriteByte('=')
+	if c.Color {
+		b.WriteString(colorReset)
+	}
+	b.Top().Count++
+	return false
+}
+
+// inStack reports whether the values being added belong to a top level stack field.
+func (c *ConsoleEncoder) inStack(b *Buffer) bool {
+	scopes := b.Scopes()
+	return len(scopes) > 1 && scopes[1].Array && scopes[1].Key == StackKey
+}
+
+// colored writes s, wrapped in the color when colors are enabled.
+func (c *ConsoleEncoder) colored(b *Buffer, color, s string) {
+	if !c.Color {
+		b.WriteString(s)
+		return
+	}
+
+	b.WriteString(color)
+	b.WriteString(s)
+	b.WriteString(colorReset)
+}
+
+func severityColor(severity string) string {
+	switch severity {
+	case SeverityDebug:
+		return colorGray
+	case SeverityInfo:
+		return colorGreen
+	case SeverityWarn:
+		return colorYellow
+	case SeverityError:
+		return colorRed
+	case SeverityPanic, SeverityFatal:
+		return colorBold + colorRed
+	}
+	return colorMagenta
+}
+
+func severityAbbreviation(severity string) string {
+	switch severity {
+	case SeverityDebug:
+		return "DBG"
+	case SeverityInfo:
+		return "INF"
+	case SeverityWarn:
+		return "WRN"
+	case SeverityError:
+		return "ERR"
+	case SeverityPanic:
+		return "PNC"
+	case SeverityFatal:
+		return "FTL"
+	}
+
+	abbr := strings.ToUpper(severity)
+	if len(abbr) > 3 {
+		abbr = abbr[:3]
+	}
+	return abbr
+}
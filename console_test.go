@@ -0,0 +1,79 @@
+package slog
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestConsoleEncoder(t *testing.T) {
+	ogWriter, ogEncoder := Writer, DefaultEncoder
+	defer func() {
+		Writer, DefaultEncoder = ogWriter, ogEncoder
+		TraceErrStack = false
+	}()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	DefaultEncoder = NewConsoleEncoder(false)
+
+	Info("user login", Int("user", 42), String("reqID", "abc"))
+	Warning("no fields")
+	TraceErrStack = true
+	_ = TraceErr(errors.New("boom"))
+
+	lines := strings.Split(b.String(), "\n")
+	if !regexp.MustCompile(`^\d\d:\d\d:\d\d\.\d{3} INF user login {23}user=42 reqID=abc$`).MatchString(lines[0]) {
+		t.Fatalf("unexpected line: %q", lines[0])
+	}
+	if !regexp.MustCompile(`^\d\d:\d\d:\d\d\.\d{3} WRN no fields$`).MatchString(lines[1]) {
+		t.Fatalf("unexpected line: %q", lines[1])
+	}
+	if !strings.Contains(lines[2], " ERR trace ") || !strings.Contains(lines[2], "err=boom") {
+		t.Fatalf("unexpected line: %q", lines[2])
+	}
+	if lines[3] != "    github.com/unrolled/slog.TestConsoleEncoder" || !strings.HasPrefix(lines[4], "        ") || !strings.Contains(lines[4], "console_test.go:") {
+		t.Fatalf("unexpected stack: %q", lines[3:])
+	}
+}
+
+func TestConsoleEncoderColor(t *testing.T) {
+	ogWriter, ogEncoder := Writer, DefaultEncoder
+	defer func() { Writer, DefaultEncoder = ogWriter, ogEncoder }()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	DefaultEncoder = NewConsoleEncoder(true)
+
+	Error("failed", Err(errors.New("boom")))
+	if !strings.Contains(b.String(), colorRed+"ERR"+colorReset) || !strings.Contains(b.String(), colorCyan+"err="+colorReset+colorRed+"boom"+colorReset) {
+		t.Fatalf("unexpected output: %q", b.String())
+	}
+}
+
+func TestAutoEncoder(t *testing.T) {
+	f, err := ioutil.TempFile("", "slog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if _, ok := AutoEncoder(f).(*JSONEncoder); !ok {
+		t.Fatal("expected JSON output for a regular file")
+	}
+
+	null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer null.Close()
+
+	if _, ok := AutoEncoder(null).(*JSONEncoder); !ok {
+		t.Fatal("expected JSON output for the null device")
+	}
+}
@@ -0,0 +1,106 @@
+package slog
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	containerIDPattern      = regexp.MustCompile(`[0-9a-f]{64}`)
+	mountContainerIDPattern = regexp.MustCompile(`/containers/([0-9a-f]{64})/`)
+	podUIDPattern           = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+)
+
+// ContainerEnricher adds the container id and pod uid found in the proc filesystem, along with the pod name,
+// namespace and node exposed through the Kubernetes downward API. Anything that cannot be detected is left out, so
+// it is safe to use off cluster.
+type ContainerEnricher struct {
+	// ProcRoot is where the proc filesystem is mounted. Defaults to "/proc".
+	ProcRoot string
+
+	// PodNameEnv, PodNamespaceEnv and NodeNameEnv are the downward API environment variables to read.
+	PodNameEnv      string
+	PodNamespaceEnv string
+	NodeNameEnv     string
+
+	// Getenv looks up environment variables. Defaults to `os.Getenv`.
+	Getenv func(string) string
+}
+
+// NewContainerEnricher returns a ContainerEnricher with the default proc root and environment variable names.
+func NewContainerEnricher() *ContainerEnricher {
+	return &ContainerEnricher{
+		ProcRoot:        "/proc",
+		PodNameEnv:      "POD_NAME",
+		PodNamespaceEnv: "POD_NAMESPACE",
+		NodeNameEnv:     "NODE_NAME",
+		Getenv:          os.Getenv,
+	}
+}
+
+// Fields implements Enricher.
+func (e *ContainerEnricher) Fields() []Field {
+	root := e.ProcRoot
+	if root == "" {
+		root = "/proc"
+	}
+	getenv := e.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+
+	containerID, podUID := "", ""
+	for _, line := range readLines(filepath.Join(root, "self", "cgroup")) {
+		if id := containerIDPattern.FindString(line); id != "" {
+			containerID = id
+		}
+		if m := podUIDPattern.FindStringSubmatch(line); m != nil {
+			podUID = strings.Replace(m[1], "_", "-", -1)
+		}
+	}
+
+	// With cgroup v2 namespaces the cgroup path is often just "/", but the runtime still mounts files from the
+	// container directory.
+	if containerID == "" {
+		for _, line := range readLines(filepath.Join(root, "self", "mountinfo")) {
+			if m := mountContainerIDPattern.FindStringSubmatch(line); m != nil {
+				containerID = m[1]
+				break
+			}
+		}
+	}
+
+	return []Field{
+		NullableString("container_id", containerID),
+		NullableString("k8s_pod_uid", podUID),
+		NullableString("k8s_pod", lookupEnv(getenv, e.PodNameEnv)),
+		NullableString("k8s_namespace", lookupEnv(getenv, e.PodNamespaceEnv)),
+		NullableString("k8s_node", lookupEnv(getenv, e.NodeNameEnv)),
+	}
+}
+
+func lookupEnv(getenv func(string) string, key string) string {
+	if key == "" {
+		return ""
+	}
+	return getenv(key)
+}
+
+// readLines returns the lines of the file, or nothing if it cannot be read.
+func readLines(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
@@ -0,0 +1,75 @@
+package slog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeProcFile(t *testing.T, root, name, content string) {
+	if err := os.MkdirAll(filepath.Join(root, "self"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "self", name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func fieldMap(fields []Field) map[string]string {
+	m := map[string]string{}
+	for _, f := range fields {
+		if f.fieldType != skipType {
+			m[f.key] = f.str
+		}
+	}
+	return m
+}
+
+func TestContainerEnricher(t *testing.T) {
+	root, err := ioutil.TempDir("", "slog-proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	id := "3f0e5f7e0b6b8f8a2c1d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293"
+	writeProcFile(t, root, "cgroup", "0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1b4c1f7e_2d3a_4b5c_8d9e_0f1a2b3c4d5e.slice/cri-containerd-"+id+".scope\n")
+
+	env := map[string]string{"POD_NAME": "api-7d9f", "POD_NAMESPACE": "prod", "NODE_NAME": "node-1"}
+	e := NewContainerEnricher()
+	e.ProcRoot = root
+	e.Getenv = func(k string) string { return env[k] }
+
+	m := fieldMap(e.Fields())
+	if m["container_id"] != id || m["k8s_pod_uid"] != "1b4c1f7e-2d3a-4b5c-8d9e-0f1a2b3c4d5e" {
+		t.Fatalf("unexpected proc fields: %v", m)
+	}
+	if m["k8s_pod"] != "api-7d9f" || m["k8s_namespace"] != "prod" || m["k8s_node"] != "node-1" {
+		t.Fatalf("unexpected env fields: %v", m)
+	}
+}
+
+func TestContainerEnricherMountinfo(t *testing.T) {
+	root, err := ioutil.TempDir("", "slog-proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	id := "a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90"
+	writeProcFile(t, root, "cgroup", "0::/\n")
+	writeProcFile(t, root, "mountinfo", "612 590 259:1 /var/lib/docker/containers/"+id+"/hostname /etc/hostname rw,relatime - ext4 /dev/root rw\n")
+
+	e := &ContainerEnricher{ProcRoot: root, Getenv: func(string) string { return "" }}
+	if m := fieldMap(e.Fields()); len(m) != 1 || m["container_id"] != id {
+		t.Fatalf("unexpected fields: %v", m)
+	}
+}
+
+func TestContainerEnricherOffCluster(t *testing.T) {
+	e := &ContainerEnricher{ProcRoot: filepath.Join(os.TempDir(), "slog-missing-proc"), Getenv: func(string) string { return "" }}
+	if m := fieldMap(e.Fields()); len(m) != 0 {
+		t.Fatalf("expected no fields: %v", m)
+	}
+}
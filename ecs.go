@@ -0,0 +1,141 @@
+package slog
+
+import (
+	"net/http"
+	"time"
+)
+
+// ECSVersion is the version of the Elastic Common Schema the ECSEncoder follows.
+const ECSVersion = "1.6.0"
+
+// ECSEncoder writes each entry as JSON following the Elastic Common Schema, nesting values the way ECS does. The
+// built in values are written as `@timestamp`, `message` and `log.level`, and errors, stacks, trace locations and
+// requests are mapped onto their ECS fields, such as `error.message`, `log.origin.file.line` and
+// `http.request.method`. Other fields are written like the JSONEncoder does.
+type ECSEncoder struct {
+	JSONEncoder
+}
+
+// NewECSEncoder returns an encoder for ECS output.
+func NewECSEncoder() *ECSEncoder {
+	return &ECSEncoder{}
+}
+
+// BeginEntry implements Encoder.
+func (e *ECSEncoder) BeginEntry(b *Buffer, entry *Entry) {
+	b.Push("", false)
+	b.WriteByte('{')
+	b.strictJSON = true
+
+	e.writeKey(b, "@timestamp")
+	appendStringValue(&b.Buffer, entry.Time.UTC().Format(time.RFC3339Nano))
+	e.writeKey(b, "message")
+	appendStrictStringValue(&b.Buffer, entry.Message)
+	e.BeginObject(b, "ecs")
+	e.AddString(b, "version", ECSVersion)
+	e.EndObject(b)
+}
+
+// EndEntry implements Encoder.
+func (e *ECSEncoder) EndEntry(b *Buffer, entry *Entry) {
+	b.release()
+
+	e.close(b, '}')
+	e.writeLineEnding(b)
+}
+
+// encodeHeld implements heldEncoder, writing the fields that were held back as ECS objects. The level is always
+// written, as part of the `log` object.
+func (e *ECSEncoder) encodeHeld(b *Buffer, entry *Entry, limit int) int {
+	var stack, origin, request *Field
+	message := ""
+	for i := range b.held {
+		f := &b.held[i]
+		switch f.fieldType {
+		case errorType:
+			// ECS has room for one error, so the messages of every error are joined.
+			if message != "" {
+				message += "; "
+			}
+			message += f.obj.(error).Error()
+		case stackType:
+			stack = f
+		case originType:
+			origin = f
+		case callerType:
+			if origin == nil || origin.fieldType == callerType {
+				origin = f
+			}
+		case requestType:
+			request = f
+		}
+	}
+
+	dropped := 0
+	if message != "" || stack != nil {
+		dropped += limitWrite(b, limit, func() {
+			e.BeginObject(b, "error")
+			if message != "" {
+				e.AddString(b, "message", b.truncate(message, MaxStringLength))
+			}
+			if stack != nil {
+				e.AddString(b, "stack_trace", formatStack(stack.obj.([]stackFrame)))
+			}
+			e.EndObject(b)
+		})
+	}
+	e.BeginObject(b, "log")
+	e.AddString(b, "level", entry.Severity)
+	if origin != nil {
+		dropped += limitWrite(b, limit, func() { e.writeOrigin(b, origin.obj.(*callerInfo)) })
+	}
+	e.EndObject(b)
+	if request != nil {
+		dropped += limitWrite(b, limit, func() { e.writeRequest(b, request.str, request.obj.(*http.Request)) })
+	}
+	return dropped
+}
+
+// encodeSemantic implements semanticEncoder, holding back the fields that are written as ECS objects.
+func (e *ECSEncoder) encodeSemantic(b *Buffer, f Field) bool {
+	switch f.fieldType {
+	case errorType, stackType, originType, callerType, requestType:
+		b.held = append(b.held, f)
+		return true
+	}
+	return false
+}
+
+func (e *ECSEncoder) writeOrigin(b *Buffer, ci *callerInfo) {
+	e.BeginObject(b, "origin")
+	e.BeginObject(b, "file")
+	e.AddString(b, "name", ci.file)
+	e.AddInt64(b, "line", int64(ci.line))
+	e.EndObject(b)
+	e.AddString(b, "function", ci.function)
+	e.EndObject(b)
+}
+
+func (e *ECSEncoder) writeRequest(b *Buffer, token string, r *http.Request) {
+	e.BeginObject(b, "http")
+	e.BeginObject(b, "request")
+	e.AddString(b, "method", r.Method)
+	if token != "" {
+		e.AddString(b, "id", token)
+	}
+	e.EndObject(b)
+	e.EndObject(b)
+
+	if r.URL != nil {
+		e.BeginObject(b, "url")
+		e.AddString(b, "path", r.URL.Path)
+		e.AddString(b, "original", b.truncate(r.URL.String(), MaxStringLength))
+		e.EndObject(b)
+	}
+
+	if ua := r.UserAgent(); ua != "" {
+		e.BeginObject(b, "user_agent")
+		e.AddString(b, "original", b.truncate(ua, MaxStringLength))
+		e.EndObject(b)
+	}
+}
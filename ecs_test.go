@@ -0,0 +1,123 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestECSEncoder(t *testing.T) {
+	ogWriter, ogEncoder, ogStack := Writer, DefaultEncoder, TraceErrStack
+	defer func() { Writer, DefaultEncoder, TraceErrStack = ogWriter, ogEncoder, ogStack }()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	DefaultEncoder = NewECSEncoder()
+	TraceErrStack = true
+
+	r := httptest.NewRequest("POST", "/users?id=7", nil)
+	r.Header.Set(RequestHeaderKey, "abc")
+	r.Header.Set("User-Agent", "curl/7.0")
+	_ = TraceErr(errors.New("boom"), Request(r), Int("user", 42), Err(errors.New("cause")))
+
+	var entry struct {
+		Timestamp string `json:"@timestamp"`
+		Message   string `json:"message"`
+		ECS       struct {
+			Version string `json:"version"`
+		} `json:"ecs"`
+		User  int `json:"user"`
+		Error struct {
+			Message    string `json:"message"`
+			StackTrace string `json:"stack_trace"`
+		} `json:"error"`
+		Log struct {
+			Level  string `json:"level"`
+			Origin struct {
+				File struct {
+					Name string `json:"name"`
+					Line int    `json:"line"`
+				} `json:"file"`
+				Function string `json:"function"`
+			} `json:"origin"`
+		} `json:"log"`
+		HTTP struct {
+			Request struct {
+				Method string `json:"method"`
+				ID     string `json:"id"`
+			} `json:"request"`
+		} `json:"http"`
+		URL struct {
+			Path string `json:"path"`
+		} `json:"url"`
+		UserAgent struct {
+			Original string `json:"original"`
+		} `json:"user_agent"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid output %s: %v", b.String(), err)
+	}
+
+	if entry.Timestamp == "" || entry.Log.Level != "error" || entry.Message != "trace" || entry.ECS.Version != ECSVersion || entry.User != 42 {
+		t.Fatalf("unexpected built in values: %s", b.String())
+	}
+	if entry.Error.Message != "boom; cause" || entry.Error.StackTrace == "" {
+		t.Fatalf("unexpected error: %s", b.String())
+	}
+	if entry.Log.Origin.File.Name == "" || entry.Log.Origin.File.Line == 0 || entry.Log.Origin.Function != "github.com/unrolled/slog.TestECSEncoder" {
+		t.Fatalf("unexpected origin: %s", b.String())
+	}
+	if entry.HTTP.Request.Method != "POST" || entry.HTTP.Request.ID != "abc" || entry.URL.Path != "/users" || entry.UserAgent.Original != "curl/7.0" {
+		t.Fatalf("unexpected request: %s", b.String())
+	}
+}
+
+func TestECSEncoderEscaping(t *testing.T) {
+	ogWriter, ogEncoder := Writer, DefaultEncoder
+	defer func() { Writer, DefaultEncoder = ogWriter, ogEncoder }()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	DefaultEncoder = NewECSEncoder()
+
+	Info(`a\b "c"`, String(`C:\tmp`, `C:\tmp`), JsonString("q", `"quoted"`), Float64("nan", math.NaN()))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid output %s: %v", b.String(), err)
+	}
+	if entry["message"] != `a\b "c"` || entry[`C:\tmp`] != `C:\tmp` || entry["q"] != `"quoted"` || entry["nan"] != "NaN" {
+		t.Fatalf("unexpected output: %s", b.String())
+	}
+}
+
+func TestECSEncoderLineLimit(t *testing.T) {
+	ogWriter, ogEncoder := Writer, DefaultEncoder
+	defer func() { Writer, DefaultEncoder, MaxLineBytes = ogWriter, ogEncoder, 0 }()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	DefaultEncoder = NewECSEncoder()
+	MaxLineBytes = 1024
+
+	_ = TraceErr(errors.New(strings.Repeat("x", 5000)), Int("user", 42), String("small", "ok"))
+
+	if b.Len() > 1024 {
+		t.Fatalf("line too long: %d", b.Len())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid output %s: %v", b.String(), err)
+	}
+	log, _ := entry["log"].(map[string]interface{})
+	if entry["error"] != nil || entry["user"] != float64(42) || entry["small"] != "ok" || entry[truncatedKey] == nil {
+		t.Fatalf("expected the error to be dropped: %s", b.String())
+	}
+	if log["level"] != "error" || log["origin"] == nil {
+		t.Fatalf("expected the level and origin to be kept: %s", b.String())
+	}
+}
@@ -0,0 +1,327 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Entry holds the built in values of a log message.
+type Entry struct {
+	Severity string
+	Message  string
+	Time     time.Time
+}
+
+// An Encoder formats log entries into a Buffer. Encoders are shared between goroutines, so any per entry state
+// belongs in the Buffer and its scopes. `BeginEntry` is expected to open a scope for the entry, and every `Add`
+// func to bump the count of the innermost scope.
+type Encoder interface {
+	// BeginEntry starts an entry and writes the built in values that lead it.
+	BeginEntry(b *Buffer, e *Entry)
+
+	// EndEntry writes the built in values that trail the entry and finishes it.
+	EndEntry(b *Buffer, e *Entry)
+
+	AddBool(b *Buffer, key string, val bool)
+	AddInt64(b *Buffer, key string, val int64)
+	AddUint64(b *Buffer, key string, val uint64)
+	AddFloat64(b *Buffer, key string, val float64)
+	AddString(b *Buffer, key, val string)
+
+	// AddJSONString adds a string holding serialized JSON. Encoders without special handling can treat it like
+	// AddString.
+	AddJSONString(b *Buffer, key, val string)
+
+	// AddRawJSON adds an already validated JSON value.
+	AddRawJSON(b *Buffer, key string, val []byte)
+
+	AddNull(b *Buffer, key string)
+
+	// BeginObject and BeginArray open a nested value under the key. Values added to an array have an empty key.
+	BeginObject(b *Buffer, key string)
+	EndObject(b *Buffer)
+	BeginArray(b *Buffer, key string)
+	EndArray(b *Buffer)
+}
+
+// cacheableEncoder is implemented by encoders that write top level fields the same way in every entry, which lets
+// the global fields be encoded once and copied.
+type cacheableEncoder interface {
+	cacheable()
+}
+
+// encodeEntry writes a full entry, enforcing MaxFields and MaxLineBytes.
+func encodeEntry(enc Encoder, b *Buffer, e *Entry, caller Field, fields []Field, stack Field, provided []Field) {
+	start := b.Len()
+	fieldsEnd := encodeEntryWithin(enc, b, e, caller, fields, stack, provided, 0)
+	if MaxLineBytes <= 0 || b.Len()-start <= MaxLineBytes {
+		return
+	}
+
+	// The line is too long, so the entry is written again with the fields limited to the space left once what
+	// follows them, and the marker for the dropped fields, are accounted for. The dropped bytes can't outnumber
+	// the bytes of the first attempt, which bounds the size of the marker. Values an encoder always writes among
+	// the fields, like the level within the ECS `log` object, can still push the line over, in which case the
+	// limit is lowered by as much and the entry written again.
+	end := start + MaxLineBytes
+	limit := end - (b.Len() - fieldsEnd) - truncationMarkerSize(enc, b.Len()-start)
+	b.Truncate(start)
+	for limit > start {
+		encodeEntryWithin(enc, b, e, caller, fields, stack, provided, limit)
+		if b.Len() <= end {
+			return
+		}
+		limit -= b.Len() - end
+		b.Truncate(start)
+	}
+
+	// The built in values alone don't fit, so every field and marker is left out and the message is cut down
+	// until the line does. Each pass cuts at least as much as the line was over by.
+	b.truncated = true
+	short := *e
+	encodeEntryWithin(enc, b, &short, caller, fields, stack, provided, dropFields)
+	for b.Len() > end && short.Message != "" {
+		keep := len(short.Message) - (b.Len() - end) - len(truncationMarker(len(e.Message)))
+		if keep > 0 {
+			short.Message = cutString(e.Message, keep)
+		} else {
+			short.Message = ""
+		}
+
+		b.Truncate(start)
+		encodeEntryWithin(enc, b, &short, caller, fields, stack, provided, dropFields)
+	}
+}
+
+// dropFields has encodeEntryWithin leave out every field along with the truncation markers.
+const dropFields = -1
+
+// truncationMarkerSize returns how many bytes enc adds for the marker of n dropped bytes.
+func truncationMarkerSize(enc Encoder, n int) int {
+	scratch := bufPool.get()
+	defer bufPool.put(scratch)
+
+	// A value already in the scope makes the encoder write a separator, as it would in an entry.
+	scratch.Push("", false)
+	scratch.Top().Count++
+	enc.AddString(scratch, truncatedKey, truncationMarker(n))
+	return scratch.Len()
+}
+
+// encodeEntryWithin writes a full entry, dropping any field that would end past limit, or every field when limit
+// is dropFields. It returns where the fields ended.
+func encodeEntryWithin(enc Encoder, b *Buffer, e *Entry, caller Field, fields []Field, stack Field, provided []Field, limit int) int {
+	markers := limit != dropFields
+	if !markers {
+		limit = 1
+	}
+
+	enc.BeginEntry(b, e)
+	caller.encode(enc, b)
+
+	droppedFields := 0
+	if MaxFields > 0 && len(fields) > MaxFields {
+		droppedFields = len(fields) - MaxFields
+		fields = fields[:MaxFields]
+		b.truncated = true
+	}
+
+	droppedBytes := 0
+
+	// Start with the passed in fields.
+	for _, f := range fields {
+		droppedBytes += encodeLimited(enc, b, f, limit)
+	}
+	droppedBytes += encodeLimited(enc, b, stack, limit)
+
+	// Add in the global fields last, encoded ahead of time when the encoder allows it.
+	g := loadGlobals()
+	if ef := g.encodedFor(enc); ef != nil {
+		start := 0
+		for i, end := range ef.ends {
+			droppedBytes += appendLimitedBytes(b, ef.encoded[start:end], ef.counts[i], limit)
+			start = end
+		}
+	} else {
+		for _, gf := range g.fields {
+			droppedBytes += encodeLimited(enc, b, gf, limit)
+		}
+	}
+
+	// Then the provider fields, which are evaluated for every entry.
+	for _, pf := range provided {
+		droppedBytes += encodeLimited(enc, b, pf, limit)
+	}
+
+	// And last the fields the encoder held back to write its own way.
+	if he, ok := enc.(heldEncoder); ok {
+		droppedBytes += he.encodeHeld(b, e, limit)
+	}
+	fieldsEnd := b.Len()
+
+	if droppedFields > 0 && markers {
+		enc.AddInt64(b, truncatedFieldsKey, int64(droppedFields))
+	}
+	if droppedBytes > 0 && markers {
+		enc.AddString(b, truncatedKey, truncationMarker(droppedBytes))
+		b.truncated = true
+	}
+
+	enc.EndEntry(b, e)
+	return fieldsEnd
+}
+
+// semanticEncoder is implemented by encoders that write fields with a meaning of their own, like errors and
+// requests, under their own names. It returns false for the fields it writes the usual way.
+type semanticEncoder interface {
+	encodeSemantic(b *Buffer, f Field) bool
+}
+
+// heldEncoder is implemented by semantic encoders that hold fields back until every other field is written. It
+// writes them, dropping any that would end past limit, and returns the bytes dropped.
+type heldEncoder interface {
+	encodeHeld(b *Buffer, e *Entry, limit int) int
+}
+
+func (f Field) encode(enc Encoder, b *Buffer) {
+	if se, ok := enc.(semanticEncoder); ok && se.encodeSemantic(b, f) {
+		return
+	}
+
+	switch f.fieldType {
+	case boolType:
+		enc.AddBool(b, f.key, f.ival == 1)
+	case floatType:
+		enc.AddFloat64(b, f.key, math.Float64frombits(uint64(f.ival)))
+	case intType, int64Type:
+		enc.AddInt64(b, f.key, f.ival)
+	case uintType, uint64Type, uintptrType:
+		enc.AddUint64(b, f.key, uint64(f.ival))
+	case stringType:
+		if f.ival == 1 {
+			enc.AddString(b, f.key, f.str)
+			break
+		}
+		enc.AddString(b, f.key, b.truncate(f.str, MaxStringLength))
+	case jsonStringType:
+		enc.AddJSONString(b, f.key, b.truncate(f.str, MaxStringLength))
+	case errorType:
+		enc.AddString(b, f.key, b.truncate(f.obj.(error).Error(), MaxStringLength))
+	case skipType:
+		break
+	case rawType:
+		// Invalid values are written as an escaped string, along with a marker, so the line stays valid.
+		if f.ival == 1 || json.Valid(f.raw) {
+			enc.AddRawJSON(b, f.key, f.raw)
+		} else {
+			enc.AddJSONString(b, f.key, string(f.raw))
+			enc.AddBool(b, f.key+invalidJSONSuffix, true)
+		}
+	case structType:
+		encodeStruct(enc, b, f.key, f.obj)
+	case callerType:
+		enc.AddString(b, f.key, f.obj.(*callerInfo).String())
+	case stackType:
+		encodeStack(enc, b, f.key, f.obj.([]stackFrame))
+	case originType:
+		ci := f.obj.(*callerInfo)
+		enc.AddString(b, "file", ci.file)
+		enc.AddInt64(b, "line", int64(ci.line))
+		enc.AddString(b, "func", ci.function)
+	case requestType:
+		if f.str != "" {
+			enc.AddString(b, f.key, f.str)
+		}
+	case traceType:
+		tc := f.obj.(*traceContext)
+		enc.AddString(b, TraceIDKey, tc.traceID)
+		enc.AddString(b, SpanIDKey, tc.spanID)
+	default:
+		panic(fmt.Sprintf("unknown field type found: %v", f))
+	}
+}
+
+// encodeJSONValue adds raw JSON as native values, for encoders with their own representation of objects and
+// arrays. Anything that does not decode is added as a string instead.
+func encodeJSONValue(enc Encoder, b *Buffer, key string, raw []byte) {
+	mark, auxMark, depth, count := b.Len(), b.aux.Len(), len(b.scopes), b.Top().Count
+	if err := transcodeJSON(enc, b, key, raw); err != nil {
+		b.Truncate(mark)
+		b.aux.Truncate(auxMark)
+		b.scopes = b.scopes[:depth]
+		b.Top().Count = count
+		enc.AddString(b, key, string(raw))
+	}
+}
+
+func transcodeJSON(enc Encoder, b *Buffer, key string, raw []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	open := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		if open > 0 {
+			if b.Top().Array {
+				key = ""
+			} else if s, ok := tok.(string); ok {
+				// Values are read along with their key, so any string here is a key.
+				key = s
+				if tok, err = dec.Token(); err != nil {
+					return err
+				}
+			}
+		}
+
+		switch v := tok.(type) {
+		case json.Delim:
+			switch v {
+			case '{':
+				enc.BeginObject(b, key)
+				open++
+			case '[':
+				enc.BeginArray(b, key)
+				open++
+			case '}':
+				enc.EndObject(b)
+				open--
+			case ']':
+				enc.EndArray(b)
+				open--
+			}
+		case string:
+			enc.AddString(b, key, v)
+		case json.Number:
+			if i, err := v.Int64(); err == nil {
+				enc.AddInt64(b, key, i)
+			} else if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+				enc.AddUint64(b, key, u)
+			} else if f, err := v.Float64(); err == nil {
+				enc.AddFloat64(b, key, f)
+			} else {
+				enc.AddString(b, key, v.String())
+			}
+		case bool:
+			enc.AddBool(b, key, v)
+		case nil:
+			enc.AddNull(b, key)
+		}
+
+		if open == 0 {
+			if _, err := dec.Token(); err != io.EOF {
+				return errors.New("slog: trailing data after JSON value")
+			}
+			return nil
+		}
+	}
+}
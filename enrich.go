@@ -0,0 +1,84 @@
+package slog
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+)
+
+// An Enricher describes the environment the process is running in as fields.
+type Enricher interface {
+	Fields() []Field
+}
+
+// Enrich adds the fields of each enricher to the global fields, replacing any global fields that share a key. The
+// fields are encoded once, so this is meant to be called at startup.
+func Enrich(enrichers ...Enricher) {
+	for _, e := range enrichers {
+		ReplaceGlobalFields(e.Fields()...)
+	}
+}
+
+// ProcessEnricher adds the hostname, pid, executable, Go version and build information of the running binary, along
+// with the service name and environment read from the configured environment variables.
+type ProcessEnricher struct {
+	// ServiceNameEnv is the environment variable holding the service name. Defaults to "SERVICE_NAME".
+	ServiceNameEnv string
+
+	// ServiceEnvEnv is the environment variable holding the deployment environment. Defaults to "SERVICE_ENV".
+	ServiceEnvEnv string
+
+	// Getenv looks up environment variables. Defaults to `os.Getenv`.
+	Getenv func(string) string
+}
+
+// NewProcessEnricher returns a ProcessEnricher with the default environment variable names.
+func NewProcessEnricher() *ProcessEnricher {
+	return &ProcessEnricher{
+		ServiceNameEnv: "SERVICE_NAME",
+		ServiceEnvEnv:  "SERVICE_ENV",
+		Getenv:         os.Getenv,
+	}
+}
+
+// Fields implements Enricher.
+func (e *ProcessEnricher) Fields() []Field {
+	getenv := e.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+
+	host, _ := os.Hostname()
+	exe, _ := os.Executable()
+	if exe != "" {
+		exe = filepath.Base(exe)
+	}
+
+	fields := []Field{
+		NullableString("host", host),
+		Int("pid", os.Getpid()),
+		NullableString("exe", exe),
+		String("go_version", runtime.Version()),
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		revision := ""
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				revision = s.Value
+			}
+		}
+
+		fields = append(fields,
+			NullableString("module", info.Main.Path),
+			NullableString("module_version", info.Main.Version),
+			NullableString("vcs_revision", revision),
+		)
+	}
+
+	return append(fields,
+		NullableString("service", lookupEnv(getenv, e.ServiceNameEnv)),
+		NullableString("env", lookupEnv(getenv, e.ServiceEnvEnv)),
+	)
+}
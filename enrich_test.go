@@ -0,0 +1,58 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestProcessEnricher(t *testing.T) {
+	env := map[string]string{"APP_NAME": "api", "SERVICE_NAME": "ignored"}
+	e := NewProcessEnricher()
+	e.ServiceNameEnv, e.ServiceEnvEnv = "APP_NAME", "APP_ENV"
+	e.Getenv = func(k string) string { return env[k] }
+
+	m := fieldMap(e.Fields())
+	if m["service"] != "api" || m["go_version"] != runtime.Version() {
+		t.Fatalf("unexpected fields: %v", m)
+	}
+	if _, ok := m["env"]; ok {
+		t.Fatalf("expected empty env to be skipped: %v", m)
+	}
+}
+
+type staticEnricher []Field
+
+func (s staticEnricher) Fields() []Field {
+	return s
+}
+
+func TestEnrichReplacesKeys(t *testing.T) {
+	ogWriter := Writer
+	defer func() {
+		Writer = ogWriter
+		globalMu.Lock()
+		storeGlobals(nil)
+		globalMu.Unlock()
+	}()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+
+	AddGlobalFields(String("service", "old"), String("app", "api"))
+	Enrich(staticEnricher{String("service", "new")}, staticEnricher{Int("pid", os.Getpid())})
+	Info("hello")
+
+	var jData map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &jData); err != nil {
+		t.Fatal(err)
+	}
+	if jData["service"] != "new" || jData["app"] != "api" || jData["pid"] != float64(os.Getpid()) {
+		t.Fatalf("unexpected output: %s", b.String())
+	}
+	if bytes.Count(b.Bytes(), []byte(`"service"`)) != 1 {
+		t.Fatalf("expected a single service key: %s", b.String())
+	}
+}
@@ -1,7 +1,7 @@
 package slog
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -25,6 +25,12 @@ const (
 	errorType
 	skipType
 	rawType
+	structType
+	callerType
+	stackType
+	originType
+	requestType
+	traceType
 )
 
 type Field struct {
@@ -36,6 +42,40 @@ type Field struct {
 	obj       interface{}
 }
 
+// Key returns the key the field is written under.
+func (f Field) Key() string {
+	return f.key
+}
+
+// Value returns what the field holds: a bool, int64, uint64, float64, string, error, json.RawMessage or the value
+// given to `Struct`. Caller and stack fields return their text form, request fields the request token, trace
+// fields the trace id, and skipped fields nil.
+func (f Field) Value() interface{} {
+	switch f.fieldType {
+	case boolType:
+		return f.ival == 1
+	case floatType:
+		return math.Float64frombits(uint64(f.ival))
+	case intType, int64Type:
+		return f.ival
+	case uintType, uint64Type, uintptrType:
+		return uint64(f.ival)
+	case stringType, jsonStringType, requestType:
+		return f.str
+	case errorType, structType:
+		return f.obj
+	case rawType:
+		return json.RawMessage(f.raw)
+	case callerType, originType:
+		return f.obj.(*callerInfo).String()
+	case stackType:
+		return formatStack(f.obj.([]stackFrame))
+	case traceType:
+		return f.obj.(*traceContext).traceID
+	}
+	return nil
+}
+
 func Skip() Field {
 	return Field{fieldType: skipType}
 }
@@ -88,10 +128,24 @@ func JsonString(key string, val string) Field {
 	return Field{key: key, fieldType: jsonStringType, str: val}
 }
 
+// RawJSON outputs the value as is after validating it. Invalid values are written as an escaped string along
+// with a `<key>_invalid_json` marker so the line stays valid.
 func RawJSON(key string, val []byte) Field {
 	return Field{key: key, fieldType: rawType, raw: val}
 }
 
+// TrustedRawJSON outputs the value as is without validating it, for hot paths where the value is known to be
+// well formed.
+func TrustedRawJSON(key string, val []byte) Field {
+	return Field{key: key, fieldType: rawType, ival: 1, raw: val}
+}
+
+// exactString is a string field that is never truncated, for values that are useless once cut such as digests
+// and ciphertexts.
+func exactString(key string, val string) Field {
+	return Field{key: key, fieldType: stringType, ival: 1, str: val}
+}
+
 func Jsonify(key string, val interface{}) Field {
 	if val == nil {
 		return Skip()
@@ -111,7 +165,7 @@ func Err(err error) Field {
 	if err == nil {
 		return Skip()
 	}
-	return Field{key: "err", fieldType: errorType, obj: err}
+	return Field{key: ErrorKey, fieldType: errorType, obj: err}
 }
 
 func Time(key string, val time.Time) Field {
@@ -126,8 +180,15 @@ func Duration(key string, val time.Duration) Field {
 	return Int64(key, int64(val))
 }
 
+// Request outputs the request token of r. Encoders such as the ECSEncoder also pick up details of the request
+// itself, like the method and path.
 func Request(r *http.Request) Field {
-	if token := r.Header.Get(RequestHeaderKey); token != "" {
+	return Field{key: RequestFieldKey, fieldType: requestType, str: r.Header.Get(RequestHeaderKey), obj: r}
+}
+
+// RequestContext is the `Request` field for a context carrying a request token.
+func RequestContext(ctx context.Context) Field {
+	if token := RequestID(ctx); token != "" {
 		return String(RequestFieldKey, token)
 	}
 
@@ -141,34 +202,3 @@ func Raw(key string, val interface{}) Field {
 
 	return String(key, fmt.Sprintf("%#v", val))
 }
-
-func (f Field) appendField(b *bytes.Buffer) {
-	switch f.fieldType {
-	case boolType:
-		appendBool(b, f.key, f.ival == 1)
-	case floatType:
-		appendFloat64(b, f.key, math.Float64frombits(uint64(f.ival)))
-	case intType:
-		appendInt(b, f.key, int(f.ival))
-	case int64Type:
-		appendInt64(b, f.key, f.ival)
-	case uintType:
-		appendUint(b, f.key, uint(f.ival))
-	case uint64Type:
-		appendUint64(b, f.key, uint64(f.ival))
-	case uintptrType:
-		appendUintptr(b, f.key, uintptr(f.ival))
-	case stringType:
-		appendString(b, f.key, f.str)
-	case jsonStringType:
-		appendJsonString(b, f.key, f.str)
-	case errorType:
-		appendString(b, f.key, f.obj.(error).Error())
-	case skipType:
-		break
-	case rawType:
-		appendRaw(b, f.key, f.raw)
-	default:
-		panic(fmt.Sprintf("unknown field type found: %v", f))
-	}
-}
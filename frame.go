@@ -0,0 +1,71 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	frameHeaderSize = 4
+
+	// maxFrameSize guards ReadFrame against reading a corrupt length.
+	maxFrameSize = 64 << 20
+
+	// maxDecodeDepth limits how deeply the decoders follow nested values.
+	maxDecodeDepth = 512
+)
+
+var errTruncatedInput = errors.New("slog: unexpected end of input")
+
+// beginFrame reserves space for the length of the entry that follows.
+func beginFrame(b *Buffer) {
+	b.Write([]byte{0, 0, 0, 0})
+}
+
+// endFrame fills in the length of the entry that was framed at start.
+func endFrame(b *Buffer, start int) {
+	binary.BigEndian.PutUint32(b.Bytes()[start:], uint32(b.Len()-start-frameHeaderSize))
+}
+
+// ReadFrame reads the next entry written by a binary encoder with framing enabled. Each entry is prefixed with
+// its length as a 4 byte big endian integer.
+func ReadFrame(r io.Reader) ([]byte, error) {
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	n := binary.BigEndian.Uint32(header[:])
+	if n > maxFrameSize {
+		return nil, fmt.Errorf("slog: frame of %d bytes is too large", n)
+	}
+
+	frame := make([]byte, n)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return frame, nil
+}
+
+// appendDecodedString writes s as a JSON string for the decoders, which have to produce valid JSON.
+func appendDecodedString(out *bytes.Buffer, s []byte) {
+	quoted, _ := json.Marshal(string(s))
+	out.Write(quoted)
+}
+
+// appendDecodedKey writes a decoded map key, quoting keys that are not already strings.
+func appendDecodedKey(out *bytes.Buffer, key []byte) {
+	if len(key) > 0 && key[0] == '"' {
+		out.Write(key)
+	} else {
+		appendDecodedString(out, key)
+	}
+	out.WriteByte(':')
+}
@@ -0,0 +1,172 @@
+package slog
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	gcpTraceKey          = "logging.googleapis.com/trace"
+	gcpSpanIDKey         = "logging.googleapis.com/spanId"
+	gcpTraceSampledKey   = "logging.googleapis.com/trace_sampled"
+	gcpSourceLocationKey = "logging.googleapis.com/sourceLocation"
+)
+
+// GCPEncoder writes each entry as the structured JSON understood by Google Cloud Logging. The built in values are
+// written as `severity`, `message` and `timestamp`. Trace locations and callers (see `EnableCaller`) become the
+// `logging.googleapis.com/sourceLocation`, `Request` fields an `httpRequest` object, and the trace of
+// `TraceParent` fields, or of the request's `traceparent` header, is linked with
+// `logging.googleapis.com/trace` and `logging.googleapis.com/spanId`. Other fields are written like the
+// JSONEncoder does.
+type GCPEncoder struct {
+	JSONEncoder
+
+	// ProjectID qualifies trace IDs, which Cloud Logging needs to link entries with their trace.
+	ProjectID string
+}
+
+// NewGCPEncoder returns an encoder for Cloud Logging in the given project. When projectID is empty the
+// GOOGLE_CLOUD_PROJECT environment variable is used.
+func NewGCPEncoder(projectID string) *GCPEncoder {
+	if projectID == "" {
+		projectID = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	return &GCPEncoder{ProjectID: projectID}
+}
+
+// BeginEntry implements Encoder.
+func (g *GCPEncoder) BeginEntry(b *Buffer, e *Entry) {
+	b.Push("", false)
+	b.WriteByte('{')
+	b.strictJSON = true
+
+	g.writeKey(b, "severity")
+	appendStringValue(&b.Buffer, gcpSeverity(e.Severity))
+	g.writeKey(b, "message")
+	appendStrictStringValue(&b.Buffer, e.Message)
+	g.writeKey(b, "timestamp")
+	appendStringValue(&b.Buffer, e.Time.UTC().Format(time.RFC3339Nano))
+}
+
+// EndEntry implements Encoder.
+func (g *GCPEncoder) EndEntry(b *Buffer, e *Entry) {
+	b.release()
+
+	g.close(b, '}')
+	g.writeLineEnding(b)
+}
+
+// encodeHeld implements heldEncoder, writing the fields that were held back as Cloud Logging values.
+func (g *GCPEncoder) encodeHeld(b *Buffer, e *Entry, limit int) int {
+	var origin, request, trace *Field
+	for i := range b.held {
+		f := &b.held[i]
+		switch f.fieldType {
+		case originType:
+			origin = f
+		case callerType:
+			if origin == nil || origin.fieldType == callerType {
+				origin = f
+			}
+		case requestType:
+			request = f
+		case traceType:
+			trace = f
+		}
+	}
+
+	dropped := 0
+	if origin != nil {
+		ci := origin.obj.(*callerInfo)
+		dropped += limitWrite(b, limit, func() {
+			g.BeginObject(b, gcpSourceLocationKey)
+			g.AddString(b, "file", ci.file)
+			g.AddString(b, "line", strconv.Itoa(ci.line))
+			g.AddString(b, "function", ci.function)
+			g.EndObject(b)
+		})
+	}
+	if request != nil {
+		r := request.obj.(*http.Request)
+		dropped += limitWrite(b, limit, func() { g.writeHTTPRequest(b, r) })
+		if trace == nil {
+			if tc, ok := parseTraceParent(r.Header.Get(TraceParentHeader)); ok {
+				dropped += limitWrite(b, limit, func() { g.writeTrace(b, tc) })
+			}
+		}
+	}
+	if trace != nil {
+		dropped += limitWrite(b, limit, func() { g.writeTrace(b, trace.obj.(*traceContext)) })
+	}
+	return dropped
+}
+
+// encodeSemantic implements semanticEncoder, holding back the fields that are written as Cloud Logging values.
+func (g *GCPEncoder) encodeSemantic(b *Buffer, f Field) bool {
+	switch f.fieldType {
+	case requestType:
+		// The request token is still written as usual, only the request itself is held back.
+		if f.str != "" {
+			g.AddString(b, f.key, f.str)
+		}
+		fallthrough
+	case originType, callerType, traceType:
+		b.held = append(b.held, f)
+		return true
+	}
+	return false
+}
+
+func (g *GCPEncoder) writeHTTPRequest(b *Buffer, r *http.Request) {
+	g.BeginObject(b, "httpRequest")
+	g.AddString(b, "requestMethod", r.Method)
+	if r.URL != nil {
+		g.AddString(b, "requestUrl", b.truncate(r.URL.String(), MaxStringLength))
+	}
+	if ua := r.UserAgent(); ua != "" {
+		g.AddString(b, "userAgent", b.truncate(ua, MaxStringLength))
+	}
+	if ref := r.Referer(); ref != "" {
+		g.AddString(b, "referer", b.truncate(ref, MaxStringLength))
+	}
+	if r.RemoteAddr != "" {
+		g.AddString(b, "remoteIp", r.RemoteAddr)
+	}
+	g.AddString(b, "protocol", r.Proto)
+	g.EndObject(b)
+}
+
+func (g *GCPEncoder) writeTrace(b *Buffer, tc *traceContext) {
+	trace := tc.traceID
+	if g.ProjectID != "" {
+		trace = "projects/" + g.ProjectID + "/traces/" + tc.traceID
+	}
+
+	g.AddString(b, gcpTraceKey, trace)
+	g.AddString(b, gcpSpanIDKey, tc.spanID)
+	g.writeKey(b, gcpTraceSampledKey)
+	if tc.sampled {
+		b.WriteString("true")
+	} else {
+		b.WriteString("false")
+	}
+}
+
+// gcpSeverity maps a severity onto the Cloud Logging severities.
+func gcpSeverity(severity string) string {
+	switch severity {
+	case SeverityDebug:
+		return "DEBUG"
+	case SeverityInfo:
+		return "INFO"
+	case SeverityWarn:
+		return "WARNING"
+	case SeverityError:
+		return "ERROR"
+	case SeverityPanic, SeverityFatal:
+		return "CRITICAL"
+	}
+	return "DEFAULT"
+}
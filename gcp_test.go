@@ -0,0 +1,115 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGCPEncoder(t *testing.T) {
+	ogWriter, ogEncoder, ogCaller := Writer, DefaultEncoder, EnableCaller
+	defer func() { Writer, DefaultEncoder, EnableCaller = ogWriter, ogEncoder, ogCaller }()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	DefaultEncoder = NewGCPEncoder("my-project")
+	EnableCaller = true
+
+	r := httptest.NewRequest("GET", "/users/7", nil)
+	r.Header.Set(RequestHeaderKey, "abc")
+	r.Header.Set(TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	Warning(`slow "request"`, Request(r), Int("ms", 900), String("dir", `C:\tmp`), Float64("ratio", math.Inf(1)))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid output %s: %v", b.String(), err)
+	}
+
+	expected := map[string]interface{}{
+		"severity":                             "WARNING",
+		"message":                              `slow "request"`,
+		"dir":                                  `C:\tmp`,
+		"ratio":                                "+Inf",
+		RequestFieldKey:                        "abc",
+		"ms":                                   float64(900),
+		"logging.googleapis.com/trace":         "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736",
+		"logging.googleapis.com/spanId":        "00f067aa0ba902b7",
+		"logging.googleapis.com/trace_sampled": true,
+	}
+	for key, val := range expected {
+		if entry[key] != val {
+			t.Fatalf("expected %s to be %v: %s", key, val, b.String())
+		}
+	}
+
+	location, _ := entry["logging.googleapis.com/sourceLocation"].(map[string]interface{})
+	if location["function"] != "github.com/unrolled/slog.TestGCPEncoder" || location["line"] == "" {
+		t.Fatalf("unexpected source location: %s", b.String())
+	}
+	request, _ := entry["httpRequest"].(map[string]interface{})
+	if request["requestMethod"] != "GET" || request["requestUrl"] != "/users/7" {
+		t.Fatalf("unexpected http request: %s", b.String())
+	}
+}
+
+func TestTraceParent(t *testing.T) {
+	valid := map[string]bool{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":       true,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00":       true,
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra": true,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra": false,
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":       false,
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01":       false,
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01":       false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902-01":         false,
+		"": false,
+	}
+	for header, ok := range valid {
+		if _, parsed := parseTraceParent(header); parsed != ok {
+			t.Fatalf("%q: expected valid to be %v", header, ok)
+		}
+	}
+
+	ogWriter := Writer
+	defer func() { Writer = ogWriter }()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	Info("traced", TraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+
+	expected := `{"level":"info", "msg":"traced", "trace_id":"4bf92f3577b34da6a3ce929d0e0e4736", "span_id":"00f067aa0ba902b7", "ts":`
+	if !bytes.HasPrefix(b.Bytes(), []byte(expected)) {
+		t.Fatalf("unexpected output: %s", b.String())
+	}
+}
+
+func TestGCPEncoderLineLimit(t *testing.T) {
+	ogWriter, ogEncoder := Writer, DefaultEncoder
+	defer func() { Writer, DefaultEncoder, MaxLineBytes = ogWriter, ogEncoder, 0 }()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	DefaultEncoder = NewGCPEncoder("my-project")
+	MaxLineBytes = 1024
+
+	r := httptest.NewRequest("GET", "/users?q="+strings.Repeat("x", 5000), nil)
+	r.Header.Set(TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	Info("slow request", Request(r), Int("ms", 900))
+
+	if b.Len() > 1024 {
+		t.Fatalf("line too long: %d", b.Len())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid output %s: %v", b.String(), err)
+	}
+	if entry["httpRequest"] != nil || entry["ms"] != float64(900) || entry[truncatedKey] == nil {
+		t.Fatalf("expected the request to be dropped: %s", b.String())
+	}
+	if entry["logging.googleapis.com/spanId"] != "00f067aa0ba902b7" {
+		t.Fatalf("expected the trace to be kept: %s", b.String())
+	}
+}
@@ -0,0 +1,338 @@
+package slog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+)
+
+const (
+	// gelfChunkSize keeps UDP datagrams below the usual ethernet MTU.
+	gelfChunkSize = 1420
+
+	gelfChunkHeaderSize = 12
+	gelfMaxChunks       = 128
+)
+
+// GELFEncoder writes each entry as a GELF 1.1 message for Graylog. The message becomes `short_message`, the
+// severity a syslog `level`, and every field an additional field prefixed with an underscore. Nested values are
+// flattened into dotted keys, since GELF only allows strings and numbers.
+type GELFEncoder struct {
+	// Host is sent as the host of every message. Defaults to the hostname.
+	Host string
+}
+
+// NewGELFEncoder returns an encoder for GELF messages from this host.
+func NewGELFEncoder() *GELFEncoder {
+	host, _ := os.Hostname()
+	return &GELFEncoder{Host: host}
+}
+
+func (g *GELFEncoder) cacheable() {}
+
+// BeginEntry implements Encoder.
+func (g *GELFEncoder) BeginEntry(b *Buffer, e *Entry) {
+	b.Push("", false)
+	b.Top().Count++
+
+	b.WriteString(`{"version":"1.1","host":`)
+	appendStrictStringValue(&b.Buffer, g.Host)
+	b.WriteString(`,"short_message":`)
+	appendStrictStringValue(&b.Buffer, e.Message)
+	b.WriteString(`,"level":`)
+	appendInt64Value(&b.Buffer, int64(syslogSeverity(e.Severity)))
+}
+
+// EndEntry implements Encoder.
+func (g *GELFEncoder) EndEntry(b *Buffer, e *Entry) {
+	b.Pop()
+	b.WriteString(`,"timestamp":`)
+	b.Write(strconv.AppendFloat(make([]byte, 0, initialFloatSize), float64(e.Time.UnixNano()/1e6)/1e3, 'f', -1, 64))
+	b.WriteByte('}')
+	b.WriteByte('\n')
+}
+
+// AddBool implements Encoder. GELF has no booleans, so the value is written as a string.
+func (g *GELFEncoder) AddBool(b *Buffer, key string, val bool) {
+	g.writeKey(b, key)
+	appendBoolValue(&b.Buffer, val)
+}
+
+// AddInt64 implements Encoder.
+func (g *GELFEncoder) AddInt64(b *Buffer, key string, val int64) {
+	g.writeKey(b, key)
+	appendInt64Value(&b.Buffer, val)
+}
+
+// AddUint64 implements Encoder.
+func (g *GELFEncoder) AddUint64(b *Buffer, key string, val uint64) {
+	g.writeKey(b, key)
+	appendUint64Value(&b.Buffer, val)
+}
+
+// AddFloat64 implements Encoder.
+func (g *GELFEncoder) AddFloat64(b *Buffer, key string, val float64) {
+	g.writeKey(b, key)
+	appendStrictFloatValue(&b.Buffer, val)
+}
+
+// AddString implements Encoder.
+func (g *GELFEncoder) AddString(b *Buffer, key, val string) {
+	g.writeKey(b, key)
+	appendStrictStringValue(&b.Buffer, val)
+}
+
+// AddJSONString implements Encoder.
+func (g *GELFEncoder) AddJSONString(b *Buffer, key, val string) {
+	g.AddString(b, key, val)
+}
+
+// AddRawJSON implements Encoder, flattening the value like any other nested value.
+func (g *GELFEncoder) AddRawJSON(b *Buffer, key string, val []byte) {
+	encodeJSONValue(g, b, key, val)
+}
+
+// AddNull implements Encoder. GELF has no nulls, so the field is left out.
+func (g *GELFEncoder) AddNull(b *Buffer, key string) {
+	b.Top().Count++
+}
+
+// BeginObject implements Encoder.
+func (g *GELFEncoder) BeginObject(b *Buffer, key string) {
+	b.Top().Count++
+	b.Push(key, false)
+}
+
+// EndObject implements Encoder.
+func (g *GELFEncoder) EndObject(b *Buffer) {
+	b.Pop()
+}
+
+// BeginArray implements Encoder.
+func (g *GELFEncoder) BeginArray(b *Buffer, key string) {
+	b.Top().Count++
+	b.Push(key, true)
+}
+
+// EndArray implements Encoder.
+func (g *GELFEncoder) EndArray(b *Buffer) {
+	b.Pop()
+}
+
+// writeKey writes the separator and the underscore prefixed, dotted key for the next value. Characters GELF does
+// not allow in keys are replaced with an underscore, and `_id`, which is reserved, becomes `__id`.
+func (g *GELFEncoder) writeKey(b *Buffer, key string) {
+	b.WriteString(`,"_`)
+	start := b.Len()
+	writeDottedKey(&b.Buffer, b.Scopes(), key)
+	b.Top().Count++
+
+	written := b.Bytes()[start:]
+	for i, c := range written {
+		if !isGELFKeyChar(c) {
+			written[i] = '_'
+		}
+	}
+	if string(written) == "id" {
+		b.Truncate(start)
+		b.WriteString("_id")
+	}
+	b.WriteString(`":`)
+}
+
+func isGELFKeyChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_' || c == '.' || c == '-'
+}
+
+// syslogSeverity maps a severity onto the syslog levels, where 0 is an emergency and 7 is debug.
+func syslogSeverity(severity string) int {
+	switch severity {
+	case SeverityDebug:
+		return 7
+	case SeverityInfo:
+		return 6
+	case SeverityWarn:
+		return 4
+	case SeverityError:
+		return 3
+	case SeverityPanic:
+		return 2
+	case SeverityFatal:
+		return 1
+	}
+	return 5
+}
+
+// A GELFOption configures a GELFWriter.
+type GELFOption func(*GELFWriter)
+
+// WithGELFCompression gzips each message sent over UDP. GELF does not allow compression over TCP.
+func WithGELFCompression() GELFOption {
+	return func(w *GELFWriter) {
+		w.compress = true
+	}
+}
+
+// WithGELFChunkSize sets the largest UDP datagram sent, splitting bigger messages into GELF chunks.
+func WithGELFChunkSize(size int) GELFOption {
+	return func(w *GELFWriter) {
+		w.chunkSize = size
+	}
+}
+
+// GELFWriter sends the messages written by a GELFEncoder to a Graylog input, over UDP with chunking or over TCP
+// with NUL framing. Every call to Write is sent as a single message.
+type GELFWriter struct {
+	mu        sync.Mutex
+	network   string
+	address   string
+	conn      net.Conn
+	compress  bool
+	chunkSize int
+}
+
+// NewGELFWriter connects to the GELF input at address, where network is "udp" or "tcp".
+func NewGELFWriter(network, address string, opts ...GELFOption) (*GELFWriter, error) {
+	w := &GELFWriter{network: network, address: address, chunkSize: gelfChunkSize}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if w.chunkSize <= gelfChunkHeaderSize {
+		return nil, errors.New("slog: GELF chunk size is too small")
+	}
+	if err := w.dial(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *GELFWriter) dial() error {
+	conn, err := net.Dial(w.network, w.address)
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+func (w *GELFWriter) isTCP() bool {
+	switch w.network {
+	case "tcp", "tcp4", "tcp6":
+		return true
+	}
+	return false
+}
+
+// Write sends p as one message, dropping the trailing newline added by the encoder.
+func (w *GELFWriter) Write(p []byte) (int, error) {
+	msg := bytes.TrimRight(p, "\n")
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		if err := w.dial(); err != nil {
+			return 0, err
+		}
+	}
+
+	var err error
+	if w.isTCP() {
+		err = w.writeTCP(msg)
+	} else {
+		err = w.writeUDP(msg)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeTCP sends the message terminated by a NUL byte, reconnecting once if the connection was lost.
+func (w *GELFWriter) writeTCP(msg []byte) error {
+	framed := make([]byte, len(msg)+1)
+	copy(framed, msg)
+
+	if _, err := w.conn.Write(framed); err == nil {
+		return nil
+	}
+
+	w.conn.Close()
+	w.conn = nil
+	if err := w.dial(); err != nil {
+		return err
+	}
+	_, err := w.conn.Write(framed)
+	return err
+}
+
+// writeUDP sends the message in a single datagram when it fits, or split into GELF chunks when it doesn't.
+func (w *GELFWriter) writeUDP(msg []byte) error {
+	if w.compress {
+		var zipped bytes.Buffer
+		zw := gzip.NewWriter(&zipped)
+		if _, err := zw.Write(msg); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		msg = zipped.Bytes()
+	}
+
+	if len(msg) <= w.chunkSize {
+		_, err := w.conn.Write(msg)
+		return err
+	}
+
+	size := w.chunkSize - gelfChunkHeaderSize
+	count := (len(msg) + size - 1) / size
+	if count > gelfMaxChunks {
+		return errors.New("slog: GELF message is too large to chunk")
+	}
+
+	chunk := make([]byte, gelfChunkHeaderSize, w.chunkSize)
+	chunk[0], chunk[1] = 0x1e, 0x0f
+	if _, err := rand.Read(chunk[2:10]); err != nil {
+		return err
+	}
+	chunk[11] = byte(count)
+
+	for i := 0; i < count; i++ {
+		end := (i + 1) * size
+		if end > len(msg) {
+			end = len(msg)
+		}
+
+		chunk[10] = byte(i)
+		chunk = append(chunk[:gelfChunkHeaderSize], msg[i*size:end]...)
+		if _, err := w.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sync implements WriteSyncer. Messages are sent as they are written, so there is nothing to flush.
+func (w *GELFWriter) Sync() error {
+	return nil
+}
+
+// Close closes the connection.
+func (w *GELFWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -0,0 +1,150 @@
+package slog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGELFEncoder(t *testing.T) {
+	ogWriter, ogEncoder := Writer, DefaultEncoder
+	defer func() { Writer, DefaultEncoder = ogWriter, ogEncoder }()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	DefaultEncoder = &GELFEncoder{Host: "web-1"}
+
+	Warning("disk low", Int("id", 7), Bool("ok", false), String("bad key", "x"), RawJSON("gone", []byte("null")),
+		Struct("disk", struct {
+			Mounts []string `slog:"mounts"`
+		}{Mounts: []string{"/", "/var"}}))
+
+	expected := `{"version":"1.1","host":"web-1","short_message":"disk low","level":4,"__id":7,"_ok":"false",` +
+		`"_bad_key":"x","_disk.mounts.0":"/","_disk.mounts.1":"/var","timestamp":`
+	if !strings.HasPrefix(b.String(), expected) {
+		t.Fatalf("unexpected output:\n%s\nexpected prefix:\n%s", b.String(), expected)
+	}
+
+	var msg map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := msg["timestamp"].(float64); !ok {
+		t.Fatalf("expected a numeric timestamp: %v", msg["timestamp"])
+	}
+
+	b.Reset()
+	Info(`say "hi"`, String("path", `C:\tmp`), Float64("ratio", math.NaN()))
+
+	msg = nil
+	if err := json.Unmarshal(b.Bytes(), &msg); err != nil {
+		t.Fatalf("%v: %s", err, b.String())
+	}
+	if msg["short_message"] != `say "hi"` || msg["_path"] != `C:\tmp` || msg["_ratio"] != "NaN" {
+		t.Fatalf("unexpected output: %s", b.String())
+	}
+}
+
+func TestGELFWriterUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	w, err := NewGELFWriter("udp", pc.LocalAddr().String(), WithGELFCompression(), WithGELFChunkSize(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// Random data doesn't compress, so the message has to be chunked.
+	msg := []byte(`{"short_message":"` + genericToken{}.Generate() + genericToken{}.Generate() + genericToken{}.Generate() + genericToken{}.Generate() + `"}` + "\n")
+	if _, err := w.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	var chunks [][]byte
+	buf := make([]byte, 128)
+	for count := -1; count != len(chunks); {
+		_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n > 64 || buf[0] != 0x1e || buf[1] != 0x0f {
+			t.Fatalf("unexpected chunk: %x", buf[:n])
+		}
+
+		count = int(buf[11])
+		if int(buf[10]) != len(chunks) {
+			t.Fatalf("unexpected sequence number %d", buf[10])
+		}
+		chunks = append(chunks, append([]byte(nil), buf[gelfChunkHeaderSize:n]...))
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(bytes.Join(chunks, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != strings.TrimSuffix(string(msg), "\n") {
+		t.Fatalf("unexpected message: %s", out)
+	}
+}
+
+func TestGELFWriterTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var msgs []string
+		r := bufio.NewReader(conn)
+		for len(msgs) < 2 {
+			msg, err := r.ReadString(0)
+			if err != nil {
+				break
+			}
+			msgs = append(msgs, msg)
+		}
+		received <- msgs
+	}()
+
+	w, err := NewGELFWriter("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	_, _ = w.Write([]byte(`{"short_message":"one"}` + "\n"))
+	_, _ = w.Write([]byte(`{"short_message":"two"}` + "\n"))
+
+	select {
+	case msgs := <-received:
+		if len(msgs) != 2 || msgs[0] != `{"short_message":"one"}`+"\x00" || msgs[1] != `{"short_message":"two"}`+"\x00" {
+			t.Fatalf("unexpected messages: %q", msgs)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for messages")
+	}
+}
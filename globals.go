@@ -0,0 +1,141 @@
+package slog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// globalMu serializes updates to the global fields, readers only ever load the current snapshot.
+	globalMu sync.Mutex
+	globals  atomic.Value // *globalSnapshot
+)
+
+// globalSnapshot is an immutable set of global fields, along with their encoded form for each encoder that
+// allows it.
+type globalSnapshot struct {
+	fields  []Field
+	encoded sync.Map // map[Encoder]*encodedFields
+}
+
+type encodedFields struct {
+	encoded []byte
+	ends    []int // end offset of each field within encoded
+	counts  []int // number of values each field added
+}
+
+func init() {
+	globals.Store(&globalSnapshot{})
+}
+
+func loadGlobals() *globalSnapshot {
+	return globals.Load().(*globalSnapshot)
+}
+
+// storeGlobals encodes the fields once and swaps them in, dropping any skipped fields. Callers must hold globalMu.
+func storeGlobals(all []Field) {
+	fields := make([]Field, 0, len(all))
+	for _, f := range all {
+		if f.fieldType != skipType {
+			fields = append(fields, f)
+		}
+	}
+
+	globals.Store(&globalSnapshot{fields: fields})
+}
+
+// encodedFor returns the fields encoded by enc, encoding them on first use. It returns nil for encoders that do
+// not allow it.
+func (g *globalSnapshot) encodedFor(enc Encoder) *encodedFields {
+	if _, ok := enc.(cacheableEncoder); !ok || len(g.fields) == 0 {
+		return nil
+	}
+	if _, ok := enc.(semanticEncoder); ok {
+		// The encoder may hold fields back until the end of the entry, which can't be done ahead of time.
+		return nil
+	}
+	if ef, ok := g.encoded.Load(enc); ok {
+		return ef.(*encodedFields)
+	}
+
+	// Encode as if the built in values came first, since they always lead the fields.
+	bp := bufPool.get()
+	bp.Push("", false)
+	bp.Top().Count = 1
+
+	ef := &encodedFields{ends: make([]int, 0, len(g.fields)), counts: make([]int, 0, len(g.fields))}
+	for _, f := range g.fields {
+		count := bp.Top().Count
+		f.encode(enc, bp)
+		ef.ends = append(ef.ends, bp.Len())
+		ef.counts = append(ef.counts, bp.Top().Count-count)
+	}
+
+	ef.encoded = make([]byte, bp.Len())
+	copy(ef.encoded, bp.Bytes())
+	bufPool.put(bp)
+
+	actual, _ := g.encoded.LoadOrStore(enc, ef)
+	return actual.(*encodedFields)
+}
+
+// AddGlobalFields allows you to set fields that will automatically be appended to all messages.
+func AddGlobalFields(fields ...Field) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	current := loadGlobals().fields
+	next := make([]Field, 0, len(current)+len(fields))
+	next = append(next, current...)
+	next = append(next, fields...)
+	storeGlobals(next)
+}
+
+// ReplaceGlobalFields swaps out any global fields sharing a key with the given fields, appending the ones that are
+// not set yet. Use it to update values such as a config version at runtime.
+func ReplaceGlobalFields(fields ...Field) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	current := loadGlobals().fields
+	next := make([]Field, 0, len(current)+len(fields))
+	for _, f := range current {
+		if !hasFieldKey(fields, f.key) {
+			next = append(next, f)
+		}
+	}
+	next = append(next, fields...)
+	storeGlobals(next)
+}
+
+// RemoveGlobalField removes every global field with the given key.
+func RemoveGlobalField(key string) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	current := loadGlobals().fields
+	next := make([]Field, 0, len(current))
+	for _, f := range current {
+		if f.key != key {
+			next = append(next, f)
+		}
+	}
+	storeGlobals(next)
+}
+
+// GlobalFields returns a copy of the current global fields.
+func GlobalFields() []Field {
+	current := loadGlobals().fields
+	out := make([]Field, len(current))
+	copy(out, current)
+	return out
+}
+
+func hasFieldKey(fields []Field, key string) bool {
+	for _, f := range fields {
+		if f.fieldType != skipType && f.key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGlobalFields(t *testing.T) {
+	ogWriter := Writer
+	defer func() {
+		Writer = ogWriter
+		globalMu.Lock()
+		storeGlobals(nil)
+		globalMu.Unlock()
+	}()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+
+	AddGlobalFields(String("app", "api"), String("config_version", "1"))
+	ReplaceGlobalFields(String("config_version", "2"), Int("shard", 7))
+	RemoveGlobalField("shard")
+
+	if fields := GlobalFields(); len(fields) != 2 || fields[1].Key() != "config_version" || fields[1].Value() != "2" {
+		t.Fatalf("unexpected global fields: %v", fields)
+	}
+
+	Info("hello")
+
+	var jData map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &jData); err != nil {
+		t.Fatal(err)
+	}
+	if jData["app"] != "api" || jData["config_version"] != "2" || jData["shard"] != nil {
+		t.Fatalf("unexpected output: %s", b.String())
+	}
+}
+
+func TestGlobalFieldsConcurrent(t *testing.T) {
+	ogWriter := Writer
+	Writer = DiscardWrapper
+	defer func() {
+		Writer = ogWriter
+		globalMu.Lock()
+		storeGlobals(nil)
+		globalMu.Unlock()
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				ReplaceGlobalFields(Int("config_version", j))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				Info("concurrent")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestGlobalFieldProviders(t *testing.T) {
+	ogWriter := Writer
+	defer func() { Writer = ogWriter }()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+
+	calls := 0
+	remove := AddGlobalFieldProvider(CachedFieldProvider(time.Hour, func() []Field {
+		calls++
+		return []Field{Int("calls", calls)}
+	}))
+
+	Info("one")
+	Info("two")
+	remove()
+	Info("three")
+
+	lines := bytes.Split(bytes.TrimSpace(b.Bytes()), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("unexpected output: %s", b.String())
+	}
+	for i, line := range lines {
+		var jData map[string]interface{}
+		if err := json.Unmarshal(line, &jData); err != nil {
+			t.Fatal(err)
+		}
+		if i < 2 && jData["calls"] != float64(1) {
+			t.Fatalf("expected cached provider value: %s", line)
+		}
+		if i == 2 && jData["calls"] != nil {
+			t.Fatalf("expected provider to be removed: %s", line)
+		}
+	}
+}
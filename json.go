@@ -0,0 +1,233 @@
+package slog
+
+import (
+	"encoding/json"
+)
+
+// JSONEncoder writes each entry as a single line of JSON. It is the `DefaultEncoder`. Its settings must not be
+// changed once it is in use.
+type JSONEncoder struct {
+	// Compact leaves out the space after each comma.
+	Compact bool
+
+	// Indent pretty prints each entry across several lines, indenting nested values with it. Meant for debugging.
+	Indent string
+
+	// LineEnding ends each entry. Defaults to "\n", some shippers want "\r\n" or a NUL byte instead.
+	LineEnding string
+}
+
+// NewJSONEncoder returns an encoder for the default JSON output.
+func NewJSONEncoder() *JSONEncoder {
+	return &JSONEncoder{}
+}
+
+// NewCompactJSONEncoder returns an encoder for JSON output without any insignificant whitespace.
+func NewCompactJSONEncoder() *JSONEncoder {
+	return &JSONEncoder{Compact: true}
+}
+
+func (j *JSONEncoder) cacheable() {}
+
+func (j *JSONEncoder) jsonLines() {}
+
+// BeginEntry implements Encoder.
+func (j *JSONEncoder) BeginEntry(b *Buffer, e *Entry) {
+	b.Push("", false)
+	b.WriteByte('{')
+
+	if !OmitLevel {
+		j.writeRawKey(b, SeverityKey)
+		if level, n, numeric := renderSeverity(e.Severity); numeric {
+			appendInt64Value(&b.Buffer, int64(n))
+		} else {
+			b.WriteByte('"')
+			b.WriteString(level)
+			b.WriteByte('"')
+		}
+	}
+
+	j.writeRawKey(b, TitleKey)
+	b.WriteByte('"')
+	b.WriteString(e.Message)
+	b.WriteByte('"')
+}
+
+// EndEntry implements Encoder. The time is added at the end... most log services pick this up automatically anyway.
+func (j *JSONEncoder) EndEntry(b *Buffer, e *Entry) {
+	if !OmitTime {
+		j.writeKey(b, TimeStampKey)
+		if len(TimeFormat) > 0 {
+			appendStringValue(&b.Buffer, e.Time.Format(TimeFormat))
+		} else {
+			appendInt64Value(&b.Buffer, e.Time.Unix())
+		}
+	}
+
+	j.close(b, '}')
+	j.writeLineEnding(b)
+}
+
+// AddBool implements Encoder.
+func (j *JSONEncoder) AddBool(b *Buffer, key string, val bool) {
+	j.writeKey(b, key)
+	appendBoolValue(&b.Buffer, val)
+}
+
+// AddInt64 implements Encoder.
+func (j *JSONEncoder) AddInt64(b *Buffer, key string, val int64) {
+	j.writeKey(b, key)
+	appendInt64Value(&b.Buffer, val)
+}
+
+// AddUint64 implements Encoder.
+func (j *JSONEncoder) AddUint64(b *Buffer, key string, val uint64) {
+	j.writeKey(b, key)
+	appendUint64Value(&b.Buffer, val)
+}
+
+// AddFloat64 implements Encoder.
+func (j *JSONEncoder) AddFloat64(b *Buffer, key string, val float64) {
+	j.writeKey(b, key)
+	if b.strictJSON {
+		appendStrictFloatValue(&b.Buffer, val)
+	} else {
+		appendFloatValue(&b.Buffer, val)
+	}
+}
+
+// AddString implements Encoder.
+func (j *JSONEncoder) AddString(b *Buffer, key, val string) {
+	j.writeKey(b, key)
+	if b.strictJSON {
+		appendStrictStringValue(&b.Buffer, val)
+	} else {
+		appendStringValue(&b.Buffer, val)
+	}
+}
+
+// AddJSONString implements Encoder.
+func (j *JSONEncoder) AddJSONString(b *Buffer, key, val string) {
+	j.writeKey(b, key)
+	if b.strictJSON {
+		appendStrictStringValue(&b.Buffer, val)
+	} else {
+		appendJsonStringValue(&b.Buffer, val)
+	}
+}
+
+// AddRawJSON implements Encoder, compacting the value if `CompactRawJSON` is set.
+func (j *JSONEncoder) AddRawJSON(b *Buffer, key string, val []byte) {
+	j.writeKey(b, key)
+	if CompactRawJSON {
+		mark := b.Len()
+		if err := json.Compact(&b.Buffer, val); err == nil {
+			return
+		}
+		b.Truncate(mark)
+	}
+	b.Write(val)
+}
+
+// AddNull implements Encoder.
+func (j *JSONEncoder) AddNull(b *Buffer, key string) {
+	j.writeKey(b, key)
+	b.WriteString("null")
+}
+
+// BeginObject implements Encoder.
+func (j *JSONEncoder) BeginObject(b *Buffer, key string) {
+	j.writeKey(b, key)
+	b.WriteByte('{')
+	b.Push(key, false)
+}
+
+// EndObject implements Encoder.
+func (j *JSONEncoder) EndObject(b *Buffer) {
+	j.close(b, '}')
+}
+
+// BeginArray implements Encoder.
+func (j *JSONEncoder) BeginArray(b *Buffer, key string) {
+	j.writeKey(b, key)
+	b.WriteByte('[')
+	b.Push(key, true)
+}
+
+// EndArray implements Encoder.
+func (j *JSONEncoder) EndArray(b *Buffer) {
+	j.close(b, ']')
+}
+
+// writeKey writes the separator and key for the next value, leaving the key out inside arrays.
+func (j *JSONEncoder) writeKey(b *Buffer, key string) {
+	top := j.writeSeparator(b)
+	if top.Array {
+		return
+	}
+
+	if b.strictJSON {
+		appendStrictStringValue(&b.Buffer, key)
+	} else {
+		b.WriteByte('"')
+		safeAppendString(&b.Buffer, key)
+		b.WriteByte('"')
+	}
+	j.writeColon(b)
+}
+
+// writeRawKey is writeKey for the built in keys, which are written as is.
+func (j *JSONEncoder) writeRawKey(b *Buffer, key []byte) {
+	j.writeSeparator(b)
+
+	b.WriteByte('"')
+	b.Write(key)
+	b.WriteByte('"')
+	j.writeColon(b)
+}
+
+func (j *JSONEncoder) writeColon(b *Buffer) {
+	b.WriteByte(':')
+	if j.Indent != "" {
+		b.WriteByte(' ')
+	}
+}
+
+func (j *JSONEncoder) writeSeparator(b *Buffer) *Scope {
+	top := b.Top()
+	if top.Count > 0 {
+		b.WriteByte(',')
+		if j.Indent == "" && !j.Compact {
+			b.WriteByte(' ')
+		}
+	}
+	top.Count++
+
+	if j.Indent != "" {
+		j.writeIndent(b, len(b.Scopes()))
+	}
+	return top
+}
+
+// close ends the innermost scope, putting the closing char on its own line when pretty printing.
+func (j *JSONEncoder) close(b *Buffer, c byte) {
+	if s := b.Pop(); j.Indent != "" && s.Count > 0 {
+		j.writeIndent(b, len(b.Scopes()))
+	}
+	b.WriteByte(c)
+}
+
+func (j *JSONEncoder) writeLineEnding(b *Buffer) {
+	if j.LineEnding == "" {
+		b.WriteByte('\n')
+	} else {
+		b.WriteString(j.LineEnding)
+	}
+}
+
+func (j *JSONEncoder) writeIndent(b *Buffer, depth int) {
+	b.WriteByte('\n')
+	for i := 0; i < depth; i++ {
+		b.WriteString(j.Indent)
+	}
+}
@@ -0,0 +1,155 @@
+package slog
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// leefTimeLayout is the default devTime format of QRadar.
+const leefTimeLayout = "Jan 02 2006 15:04:05"
+
+// LEEFEncoder writes each entry as a QRadar Log Event Extended Format 1.0 event, such as
+// `LEEF:1.0|Acme|Billing|1.0|user login|devTime=Nov 14 2023 22:13:20 sev=3 msg=user login usrName=bob`, with the
+// attributes separated by tabs. The message is used as the event ID. Fields named in Attributes are written
+// under their standard attribute names, while the rest keep their keys with anything but letters and digits
+// removed. Add it as the encoder of a syslog sink to forward events to a SIEM.
+type LEEFEncoder struct {
+	Vendor  string
+	Product string
+	Version string
+
+	// Attributes maps field keys onto standard attribute names, such as "client_ip" to "src".
+	Attributes map[string]string
+}
+
+// NewLEEFEncoder returns a LEEF encoder for the device, mapping the fields in DefaultLEEFAttributes.
+func NewLEEFEncoder(vendor, product, version string) *LEEFEncoder {
+	return &LEEFEncoder{Vendor: vendor, Product: product, Version: version, Attributes: DefaultLEEFAttributes()}
+}
+
+// DefaultLEEFAttributes returns the field keys mapped onto standard LEEF attribute names by default.
+func DefaultLEEFAttributes() map[string]string {
+	return map[string]string{
+		"src_ip": "src",
+		"dst_ip": "dst",
+		"user":   "usrName",
+		"action": "cat",
+	}
+}
+
+func (l *LEEFEncoder) cacheable() {}
+
+// BeginEntry implements Encoder.
+func (l *LEEFEncoder) BeginEntry(b *Buffer, e *Entry) {
+	b.Push("", false)
+
+	b.WriteString("LEEF:1.0|")
+	for _, val := range []string{l.Vendor, l.Product, l.Version, e.Message} {
+		appendCEFHeader(&b.Buffer, val)
+		b.WriteByte('|')
+	}
+
+	b.WriteString("devTime=")
+	b.WriteString(e.Time.Format(leefTimeLayout))
+	b.WriteString("\tsev=")
+	appendInt64Value(&b.Buffer, int64(siemSeverity(e.Severity, 1)))
+	b.WriteString("\tmsg=")
+	appendLEEFValue(&b.Buffer, e.Message)
+	b.Top().Count++
+}
+
+// EndEntry implements Encoder.
+func (l *LEEFEncoder) EndEntry(b *Buffer, e *Entry) {
+	b.Pop()
+	b.WriteByte('\n')
+}
+
+// AddBool implements Encoder.
+func (l *LEEFEncoder) AddBool(b *Buffer, key string, val bool) {
+	l.AddString(b, key, strconv.FormatBool(val))
+}
+
+// AddInt64 implements Encoder.
+func (l *LEEFEncoder) AddInt64(b *Buffer, key string, val int64) {
+	l.writeKey(b, key)
+	appendInt64Value(&b.Buffer, val)
+}
+
+// AddUint64 implements Encoder.
+func (l *LEEFEncoder) AddUint64(b *Buffer, key string, val uint64) {
+	l.writeKey(b, key)
+	appendUint64Value(&b.Buffer, val)
+}
+
+// AddFloat64 implements Encoder.
+func (l *LEEFEncoder) AddFloat64(b *Buffer, key string, val float64) {
+	l.writeKey(b, key)
+	appendFloatValue(&b.Buffer, val)
+}
+
+// AddString implements Encoder.
+func (l *LEEFEncoder) AddString(b *Buffer, key, val string) {
+	l.writeKey(b, key)
+	appendLEEFValue(&b.Buffer, val)
+}
+
+// AddJSONString implements Encoder.
+func (l *LEEFEncoder) AddJSONString(b *Buffer, key, val string) {
+	l.AddString(b, key, val)
+}
+
+// AddRawJSON implements Encoder, flattening the value like any other nested value.
+func (l *LEEFEncoder) AddRawJSON(b *Buffer, key string, val []byte) {
+	encodeJSONValue(l, b, key, val)
+}
+
+// AddNull implements Encoder. The field is left out.
+func (l *LEEFEncoder) AddNull(b *Buffer, key string) {
+	b.Top().Count++
+}
+
+// BeginObject implements Encoder.
+func (l *LEEFEncoder) BeginObject(b *Buffer, key string) {
+	b.Top().Count++
+	b.Push(key, false)
+}
+
+// EndObject implements Encoder.
+func (l *LEEFEncoder) EndObject(b *Buffer) {
+	b.Pop()
+}
+
+// BeginArray implements Encoder.
+func (l *LEEFEncoder) BeginArray(b *Buffer, key string) {
+	b.Top().Count++
+	b.Push(key, true)
+}
+
+// EndArray implements Encoder.
+func (l *LEEFEncoder) EndArray(b *Buffer) {
+	b.Pop()
+}
+
+func (l *LEEFEncoder) writeKey(b *Buffer, key string) {
+	b.WriteByte('\t')
+	writeSIEMKey(b, l.Attributes, key)
+	b.WriteByte('=')
+}
+
+// appendLEEFValue escapes backslashes, along with tabs and line breaks that would end the attribute or event.
+func appendLEEFValue(b *bytes.Buffer, val string) {
+	for i := 0; i < len(val); i++ {
+		switch c := val[i]; c {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
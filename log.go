@@ -23,11 +23,81 @@ var (
 	// TitleKey is the json key for the name of the log message.
 	TitleKey = []byte("msg")
 
+	// ErrorKey is the json key for the `Err` output.
+	ErrorKey = "err"
+
+	// LevelFormat is how the JSON, logfmt, CBOR and MessagePack encoders render the severity.
+	LevelFormat = LevelLower
+
+	// OmitTime leaves the timestamp out of messages written by the JSON, logfmt, CBOR and MessagePack encoders.
+	OmitTime = false
+
+	// OmitLevel leaves the severity out of messages written by the JSON, logfmt, CBOR and MessagePack encoders.
+	OmitLevel = false
+
+	// DefaultEncoder formats the messages written to `Writer`, and to any sink without an encoder of its own.
+	DefaultEncoder Encoder = NewJSONEncoder()
+
 	// EnableDebug will print debug logs if true.
 	EnableDebug = false
 
 	// RequestToken is the token generator for the request middleware.
 	RequestToken Token = &genericToken{}
+
+	// CompactRawJSON strips insignificant whitespace from `RawJSON` values while validating them.
+	CompactRawJSON = false
+
+	// EnableCaller adds the file, line and function of the log call to every message.
+	EnableCaller = false
+
+	// CallerKey is the json key for the caller output.
+	CallerKey = "caller"
+
+	// CallerFullPath outputs the full file path and function name for the caller, instead of the short form.
+	CallerFullPath = false
+
+	// CallerSkip is the number of extra stack frames to skip when finding the caller, for helpers wrapping the log funcs.
+	CallerSkip = 0
+
+	// TraceErrStack adds the full stack to `TraceErr` output instead of just the first frame.
+	TraceErrStack = false
+
+	// StackSeverity adds the stack to every message at or above this severity (e.g. `SeverityError`). Empty disables it.
+	StackSeverity = ""
+
+	// StackKey is the json key for the stack output.
+	StackKey = "stack"
+
+	// StackDepth is the maximum number of frames included in a stack.
+	StackDepth = 32
+
+	// TraceIDKey and SpanIDKey are the json keys for the output of `TraceParent`.
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+
+	// StackFilterRuntime leaves runtime and standard library frames out of stacks.
+	StackFilterRuntime = true
+
+	// ExitFunc is called by `Fatal` once the message is written and flushed. Override it to test `Fatal` paths.
+	ExitFunc = os.Exit
+
+	// PanicFunc is called by `Panic` once the message is written and flushed. Override it to test `Panic` paths.
+	PanicFunc = func(message string) { panic(message) }
+
+	// ExitHookTimeout is how long `Fatal` waits for the registered exit hooks before exiting anyway.
+	ExitHookTimeout = 5 * time.Second
+
+	// MaxMessageLength truncates messages longer than this many bytes. Zero means no limit.
+	MaxMessageLength = 0
+
+	// MaxStringLength truncates string and error field values longer than this many bytes. Zero means no limit.
+	MaxStringLength = 0
+
+	// MaxFields drops any fields passed to a single log call beyond this count. Zero means no limit.
+	MaxFields = 0
+
+	// MaxLineBytes drops fields that would grow a line past this many bytes. Zero means no limit.
+	MaxLineBytes = 0
 )
 
 const (
@@ -40,48 +110,65 @@ const (
 )
 
 var (
-	mu           sync.Mutex
-	globalFields = []Field{}
-
-	debugB = []byte(SeverityDebug)
-	infoB  = []byte(SeverityInfo)
-	warnB  = []byte(SeverityWarn)
-	errorB = []byte(SeverityError)
-	panicB = []byte(SeverityPanic)
-	fatalB = []byte(SeverityFatal)
-	traceB = errorB
+	mu sync.Mutex
+
+	traceSeverity = SeverityError
 )
 
-func logMessage(l, msg []byte, fields []Field) {
+func logMessage(severity, msg string, fields []Field) {
+	// The entry lives in the pooled buffer, as handing its address to the encoders would move it to the heap.
 	bp := bufPool.get()
-
-	bp.WriteByte('{')
-
-	appendKeyValue(bp, SeverityKey, l)
-	appendKeyValue(bp, TitleKey, msg)
-
-	// Start with the passed in fields.
-	for _, f := range fields {
-		f.appendField(bp)
+	e := &bp.entry
+	*e = Entry{Severity: severity, Message: truncate(msg, MaxMessageLength), Time: time.Now()}
+	truncated := len(e.Message) != len(msg)
+
+	// Gather everything that is evaluated per entry up front, so every encoder sees the same values.
+	caller, stack := Skip(), Skip()
+	if EnableCaller && !hasFieldType(fields, callerType) {
+		caller = callerField(callerDepth + CallerSkip)
 	}
-
-	// Add in the global fields last.
-	for _, gf := range globalFields {
-		gf.appendField(bp)
+	if wantsStack(severity, fields) {
+		stack = stackField(callerDepth + CallerSkip)
 	}
 
-	// Add the time at the end... most log services pick this up automatically anyway.
-	Time(TimeStampKey, time.Now()).appendField(bp)
+	var provided []Field
+	for _, rp := range loadProviders() {
+		provided = append(provided, rp.fn()...)
+	}
 
-	bp.Truncate(bp.Len() - 2) // comma and space
-	bp.WriteByte('}')
-	bp.WriteByte('\n')
+	encodeEntry(DefaultEncoder, bp, e, caller, fields, stack, provided)
+	truncated = truncated || bp.truncated
 
 	mu.Lock()
-	_, _ = bp.WriteTo(Writer)
+	_, _ = Writer.Write(bp.Bytes())
+	for _, s := range loadSinks() {
+		if s.enc == nil {
+			_, _ = s.ws.Write(bp.Bytes())
+		}
+	}
 	mu.Unlock()
 
+	// Sinks with their own encoder get their own copy of the entry.
+	for _, s := range loadSinks() {
+		if s.enc == nil {
+			continue
+		}
+
+		bp.Reset()
+		encodeEntry(s.enc, bp, e, caller, fields, stack, provided)
+		truncated = truncated || bp.truncated
+
+		mu.Lock()
+		_, _ = s.ws.Write(bp.Bytes())
+		mu.Unlock()
+	}
+
 	bufPool.put(bp)
+
+	// An entry counts once however many of its values, passes or encodings were cut.
+	if truncated {
+		countTruncation()
+	}
 }
 
 // LogFunc is the generic interface that the level funcs conform with.
@@ -90,37 +177,40 @@ type LogFunc func(message string, fields ...Field)
 // Debug outputs a debug message. If `EnabledDebug` is false, this turns into a noop.
 func Debug(message string, fields ...Field) {
 	if EnableDebug {
-		logMessage(debugB, []byte(message), fields)
+		logMessage(SeverityDebug, message, fields)
 	}
 }
 
 // Info outputs an info message.
 func Info(message string, fields ...Field) {
-	logMessage(infoB, []byte(message), fields)
+	logMessage(SeverityInfo, message, fields)
 }
 
 // Warning outputs a warning message.
 func Warning(message string, fields ...Field) {
-	logMessage(warnB, []byte(message), fields)
+	logMessage(SeverityWarn, message, fields)
 }
 
 // Error outputs an error message.
 func Error(message string, fields ...Field) {
-	logMessage(errorB, []byte(message), fields)
+	logMessage(SeverityError, message, fields)
 }
 
-// Panic outputs a panic message and also calls `panic` with the original message.
+// Panic outputs a panic message, flushes every sink and then calls `PanicFunc` (`panic` by default) with the
+// original message.
 func Panic(message string, fields ...Field) {
-	logMessage(panicB, []byte(message), fields)
-	_ = Writer.Sync()
-	panic(message)
+	logMessage(SeverityPanic, message, fields)
+	_ = Sync()
+	PanicFunc(message)
 }
 
-// Fatal outputs a fatal message and forces the application to exit with return code 1.
+// Fatal outputs a fatal message, runs the exit hooks, flushes every sink and then calls `ExitFunc` (`os.Exit` by
+// default) with return code 1.
 func Fatal(message string, fields ...Field) {
-	logMessage(fatalB, []byte(message), fields)
-	_ = Writer.Sync()
-	os.Exit(1)
+	logMessage(SeverityFatal, message, fields)
+	runExitHooks()
+	_ = Sync()
+	ExitFunc(1)
 }
 
 // TraceErr outputs the error with it's trace as an error log line, but also returns the original error.
@@ -135,21 +225,17 @@ func TraceErr(err error, fields ...Field) error {
 	frames := runtime.CallersFrames(pc[:n])
 	frame, _ := frames.Next()
 
-	traceFields := []Field{Err(err), String("file", frame.File), Int("line", frame.Line), String("func", frame.Function)}
-	logMessage(traceB, []byte("trace"), append(traceFields, fields...))
+	origin := Field{fieldType: originType, obj: newCallerInfo(frame.File, frame.Line, frame.Function)}
+	traceFields := []Field{Err(err), origin}
+	if TraceErrStack {
+		traceFields = append(traceFields, stackField(3))
+	}
+	logMessage(traceSeverity, "trace", append(traceFields, fields...))
 
 	return err
 }
 
-// AddGlobalFields allows you to set fields that will automatically be appended to all messages.
-func AddGlobalFields(fields ...Field) {
-	mu.Lock()
-	defer mu.Unlock()
-
-	globalFields = append(globalFields, fields...)
-}
-
 // SetTraceErrSeverity allows you to change the severity type for trace errors (default is "error").
 func SetTraceErrSeverity(s string) {
-	traceB = []byte(s)
+	traceSeverity = s
 }
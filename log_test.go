@@ -8,6 +8,7 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -157,3 +158,268 @@ func TestRawJSON(t *testing.T) {
 		t.Fatal()
 	}
 }
+
+func TestSizeLimits(t *testing.T) {
+	ogWriter := Writer
+	defer func() {
+		Writer = ogWriter
+		MaxMessageLength, MaxStringLength, MaxFields, MaxLineBytes = 0, 0, 0, 0
+	}()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	MaxMessageLength, MaxStringLength, MaxFields = 5, 4, 2
+	before := Truncations()
+
+	Info("hello world", String("a", "abcdefgh"), Int("b", 1), Int("c", 2))
+
+	var jData map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &jData); err != nil {
+		t.Fatal(err)
+	}
+	if jData["msg"] != "hello…(truncated 6 bytes)" {
+		t.Fatalf("unexpected msg: %v", jData["msg"])
+	}
+	if jData["a"] != "abcd…(truncated 4 bytes)" {
+		t.Fatalf("unexpected field: %v", jData["a"])
+	}
+	if _, ok := jData["c"]; ok || jData[truncatedFieldsKey] != float64(1) {
+		t.Fatalf("expected field c to be dropped: %s", b.String())
+	}
+	if Truncations()-before != 1 {
+		t.Fatalf("unexpected truncation count: %d", Truncations()-before)
+	}
+
+	b.Reset()
+	MaxMessageLength, MaxStringLength, MaxFields, MaxLineBytes = 0, 0, 0, 200
+	Info("big", String("huge", strings.Repeat("x", 500)), String("small", "ok"))
+
+	if b.Len() > 200 {
+		t.Fatalf("line too long: %d", b.Len())
+	}
+	jData = nil
+	if err := json.Unmarshal(b.Bytes(), &jData); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := jData["huge"]; ok || jData["small"] != "ok" || jData[truncatedKey] == nil {
+		t.Fatalf("unexpected output: %s", b.String())
+	}
+
+	b.Reset()
+	Info(strings.Repeat("m", 500), String("a", "b"))
+
+	if b.Len() > 200 {
+		t.Fatalf("line too long: %d", b.Len())
+	}
+	jData = nil
+	if err := json.Unmarshal(b.Bytes(), &jData); err != nil {
+		t.Fatal(err)
+	}
+	if msg, _ := jData["msg"].(string); !strings.HasPrefix(msg, "mmm") || !strings.Contains(msg, "…(truncated ") {
+		t.Fatalf("expected msg to be cut: %s", b.String())
+	}
+
+	b.Reset()
+	Info("fits", Int("i0", 0), Int("i1", 1), Int("i2", 2), Int("i3", 3), Int("i4", 4), Int("i5", 5),
+		String("pad", strings.Repeat("p", 40)))
+
+	if b.Len() > 200 || !strings.Contains(b.String(), `"i5":5`) {
+		t.Fatalf("expected every field to fit: %s", b.String())
+	}
+
+	b.Reset()
+	MaxLineBytes = 100
+	Info(strings.Repeat("m", 500), String("a", "b"))
+
+	if b.Len() > 100 || strings.Contains(b.String(), truncatedKey) {
+		t.Fatalf("expected a line of at most 100 bytes without a marker: %d %s", b.Len(), b.String())
+	}
+	jData = nil
+	if err := json.Unmarshal(b.Bytes(), &jData); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInvalidRawJSON(t *testing.T) {
+	ogWriter := Writer
+	defer func() {
+		Writer = ogWriter
+		CompactRawJSON = false
+	}()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	Info("testing raw json", RawJSON("raw", []byte(`{"foo":`)))
+
+	var jData map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &jData); err != nil {
+		t.Fatal(err)
+	}
+	if jData["raw"] != `{"foo":` || jData["raw_invalid_json"] != "true" {
+		t.Fatalf("unexpected output: %s", b.String())
+	}
+
+	b.Reset()
+	CompactRawJSON = true
+	Info("testing raw json", RawJSON("raw", []byte("{\"foo\": [1, 2]}\n")))
+
+	if !strings.Contains(b.String(), `"raw":{"foo":[1,2]}, `) {
+		t.Fatalf("unexpected output: %s", b.String())
+	}
+}
+
+func callerHelper(msg string) {
+	Info(msg)
+}
+
+func TestCaller(t *testing.T) {
+	ogWriter := Writer
+	defer func() {
+		Writer = ogWriter
+		EnableCaller, CallerSkip, CallerFullPath = false, 0, false
+	}()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	EnableCaller = true
+
+	Info("direct")
+	CallerSkip = 1
+	callerHelper("wrapped")
+	CallerFullPath = true
+	callerHelper("full")
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	for _, line := range lines {
+		var jData map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &jData); err != nil {
+			t.Fatal(err)
+		}
+		caller := jData["caller"].(string)
+		if !strings.Contains(caller, "/log_test.go:") || !strings.Contains(caller, "TestCaller") {
+			t.Fatalf("unexpected caller: %s", caller)
+		}
+	}
+	if !strings.Contains(lines[2], "github.com/unrolled/slog.TestCaller") {
+		t.Fatalf("expected full path: %s", lines[2])
+	}
+}
+
+func TestStack(t *testing.T) {
+	ogWriter := Writer
+	defer func() {
+		Writer = ogWriter
+		TraceErrStack, StackSeverity = false, ""
+	}()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	TraceErrStack = true
+	traceErrCaller("foobar", "helloworld")
+
+	TraceErrStack, StackSeverity = false, SeverityWarn
+	Info("no stack")
+	Error("with stack")
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	for i, line := range lines {
+		var jData struct {
+			Stack []struct {
+				Func string `json:"func"`
+				File string `json:"file"`
+				Line int    `json:"line"`
+			} `json:"stack"`
+		}
+		if err := json.Unmarshal([]byte(line), &jData); err != nil {
+			t.Fatalf("%v: %s", err, line)
+		}
+
+		if i == 1 {
+			if jData.Stack != nil {
+				t.Fatalf("unexpected stack: %s", line)
+			}
+			continue
+		}
+		if len(jData.Stack) == 0 || jData.Stack[len(jData.Stack)-1].Func != "github.com/unrolled/slog.TestStack" {
+			t.Fatalf("unexpected stack: %s", line)
+		}
+		for _, frame := range jData.Stack {
+			if strings.HasPrefix(frame.Func, "testing.") || strings.HasPrefix(frame.Func, "runtime.") {
+				t.Fatalf("expected stdlib frames to be filtered: %s", line)
+			}
+		}
+	}
+	if !strings.Contains(lines[0], `"stack":[{"func":"github.com/unrolled/slog.traceErrCaller"`) {
+		t.Fatalf("expected stack to start at the caller: %s", lines[0])
+	}
+}
+
+func TestStdlibFrame(t *testing.T) {
+	pc := make([]uintptr, 1)
+	runtime.Callers(1, pc)
+	frame, _ := runtime.CallersFrames(pc).Next()
+	if isStdlibFrame(frame) {
+		t.Fatalf("expected own frame to be kept: %s", frame.File)
+	}
+	if isStdlibFrame(runtime.Frame{Function: "myservice/handlers.Serve", File: "/src/myservice/handlers/serve.go"}) {
+		t.Fatal("expected module without a dot to be kept")
+	}
+	if !isStdlibFrame(runtime.Frame{Function: "testing.tRunner", File: gorootSrc + "testing/testing.go"}) {
+		t.Fatal("expected GOROOT frame to be filtered")
+	}
+
+	// Binaries built with -trimpath report files by import path.
+	ogMain := mainModule
+	defer func() { mainModule = ogMain }()
+	mainModule = "myservice"
+
+	for file, stdlib := range map[string]bool{
+		"runtime/asm_amd64.s":                     true,
+		"testing/testing.go":                      true,
+		"github.com/unrolled/slog/log_test.go":    false,
+		"myservice/handlers/serve.go":             false,
+		"mydep@v1.0.0/dep.go":                     false,
+		"example.com/mod@v1.2.3/internal/file.go": false,
+	} {
+		if isStdlibFrame(runtime.Frame{File: file}) != stdlib {
+			t.Fatalf("expected %s to be stdlib: %v", file, stdlib)
+		}
+	}
+}
+
+func TestJSONEncoderModes(t *testing.T) {
+	ogWriter, ogEncoder, ogTimeFormat := Writer, DefaultEncoder, TimeFormat
+	defer func() { Writer, DefaultEncoder, TimeFormat = ogWriter, ogEncoder, ogTimeFormat }()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	TimeFormat = "2006"
+	now := time.Now().Format(TimeFormat)
+
+	DefaultEncoder = &JSONEncoder{Compact: true, LineEnding: "\x00"}
+	Info("compact", Int("a", 1), Struct("s", map[string][]int{"b": {1, 2}}))
+	expected := `{"level":"info","msg":"compact","a":1,"s":{"b":[1,2]},"ts":"` + now + "\"}\x00"
+	if b.String() != expected {
+		t.Fatalf("unexpected output: %q", b.String())
+	}
+
+	b.Reset()
+	DefaultEncoder = &JSONEncoder{Indent: "  "}
+	Info("pretty", Struct("s", map[string][]int{"b": {1, 2}}), Struct("e", struct{}{}))
+	expected = `{
+  "level": "info",
+  "msg": "pretty",
+  "s": {
+    "b": [
+      1,
+      2
+    ]
+  },
+  "e": {},
+  "ts": "` + now + `"
+}
+`
+	if b.String() != expected {
+		t.Fatalf("unexpected output:\n%s", b.String())
+	}
+}
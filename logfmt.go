@@ -0,0 +1,262 @@
+package slog
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// LogfmtEncoder writes each entry as a line of logfmt (`level=info msg="user login" user=42`). Nested objects and
+// arrays are flattened into dotted keys, such as `user.name=bob` and `tags.0=admin`.
+type LogfmtEncoder struct{}
+
+// NewLogfmtEncoder returns an encoder for logfmt output.
+func NewLogfmtEncoder() *LogfmtEncoder {
+	return &LogfmtEncoder{}
+}
+
+func (l *LogfmtEncoder) cacheable() {}
+
+// BeginEntry implements Encoder.
+func (l *LogfmtEncoder) BeginEntry(b *Buffer, e *Entry) {
+	b.Push("", false)
+
+	if !OmitLevel {
+		l.writeRawKey(b, SeverityKey)
+		if level, n, numeric := renderSeverity(e.Severity); numeric {
+			appendInt64Value(&b.Buffer, int64(n))
+		} else {
+			appendLogfmtValue(&b.Buffer, level)
+		}
+	}
+
+	l.writeRawKey(b, TitleKey)
+	appendLogfmtValue(&b.Buffer, e.Message)
+}
+
+// EndEntry implements Encoder.
+func (l *LogfmtEncoder) EndEntry(b *Buffer, e *Entry) {
+	if !OmitTime {
+		l.writeKey(b, TimeStampKey)
+		if len(TimeFormat) > 0 {
+			appendLogfmtValue(&b.Buffer, e.Time.Format(TimeFormat))
+		} else {
+			appendInt64Value(&b.Buffer, e.Time.Unix())
+		}
+	}
+
+	b.Pop()
+	b.WriteByte('\n')
+}
+
+// AddBool implements Encoder.
+func (l *LogfmtEncoder) AddBool(b *Buffer, key string, val bool) {
+	l.writeKey(b, key)
+	if val {
+		b.WriteString("true")
+	} else {
+		b.WriteString("false")
+	}
+}
+
+// AddInt64 implements Encoder.
+func (l *LogfmtEncoder) AddInt64(b *Buffer, key string, val int64) {
+	l.writeKey(b, key)
+	appendInt64Value(&b.Buffer, val)
+}
+
+// AddUint64 implements Encoder.
+func (l *LogfmtEncoder) AddUint64(b *Buffer, key string, val uint64) {
+	l.writeKey(b, key)
+	appendUint64Value(&b.Buffer, val)
+}
+
+// AddFloat64 implements Encoder.
+func (l *LogfmtEncoder) AddFloat64(b *Buffer, key string, val float64) {
+	l.writeKey(b, key)
+	appendFloatValue(&b.Buffer, val)
+}
+
+// AddString implements Encoder.
+func (l *LogfmtEncoder) AddString(b *Buffer, key, val string) {
+	l.writeKey(b, key)
+	appendLogfmtValue(&b.Buffer, val)
+}
+
+// AddJSONString implements Encoder.
+func (l *LogfmtEncoder) AddJSONString(b *Buffer, key, val string) {
+	l.AddString(b, key, val)
+}
+
+// AddRawJSON implements Encoder. The value is written as a quoted string.
+func (l *LogfmtEncoder) AddRawJSON(b *Buffer, key string, val []byte) {
+	l.writeKey(b, key)
+	appendLogfmtQuoted(&b.Buffer, val)
+}
+
+// AddNull implements Encoder.
+func (l *LogfmtEncoder) AddNull(b *Buffer, key string) {
+	l.writeKey(b, key)
+	b.WriteString("null")
+}
+
+// BeginObject implements Encoder.
+func (l *LogfmtEncoder) BeginObject(b *Buffer, key string) {
+	b.Top().Count++
+	b.Push(key, false)
+}
+
+// EndObject implements Encoder.
+func (l *LogfmtEncoder) EndObject(b *Buffer) {
+	b.Pop()
+}
+
+// BeginArray implements Encoder.
+func (l *LogfmtEncoder) BeginArray(b *Buffer, key string) {
+	b.Top().Count++
+	b.Push(key, true)
+}
+
+// EndArray implements Encoder.
+func (l *LogfmtEncoder) EndArray(b *Buffer) {
+	b.Pop()
+}
+
+// writeKey writes the separator and the dotted key for the next value.
+func (l *LogfmtEncoder) writeKey(b *Buffer, key string) {
+	scopes := b.Scopes()
+	if scopes[0].Count > 0 {
+		b.WriteByte(' ')
+	}
+
+	writeDottedKey(&b.Buffer, scopes, key)
+	b.Top().Count++
+	b.WriteByte('=')
+}
+
+// writeRawKey is writeKey for the built in keys.
+func (l *LogfmtEncoder) writeRawKey(b *Buffer, key []byte) {
+	if b.Scopes()[0].Count > 0 {
+		b.WriteByte(' ')
+	}
+
+	b.Write(key)
+	b.Top().Count++
+	b.WriteByte('=')
+}
+
+// writeDottedKey writes the path of open scopes below the entry followed by the key. Values inside arrays are
+// keyed by their index.
+func writeDottedKey(b *bytes.Buffer, scopes []Scope, key string) {
+	for i := 1; i < len(scopes); i++ {
+		writeScopeKey(b, scopes, i)
+		b.WriteByte('.')
+	}
+
+	if top := scopes[len(scopes)-1]; top.Array {
+		appendInt64Value(b, int64(top.Count))
+	} else {
+		appendLogfmtKey(b, key)
+	}
+}
+
+// writeScopeKey writes the key of the scope at i, which is its index when the parent is an array.
+func writeScopeKey(b *bytes.Buffer, scopes []Scope, i int) {
+	if parent := scopes[i-1]; parent.Array {
+		appendInt64Value(b, int64(parent.Count-1))
+		return
+	}
+	appendLogfmtKey(b, scopes[i].Key)
+}
+
+// appendLogfmtKey writes the key, replacing anything that would break the pair with an underscore.
+func appendLogfmtKey(b *bytes.Buffer, key string) {
+	if key == "" {
+		b.WriteByte('_')
+		return
+	}
+
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			b.WriteByte('_')
+		} else {
+			b.WriteByte(c)
+		}
+	}
+}
+
+// appendLogfmtValue writes the value as is, or quoted when it is empty or holds spaces, quotes, equal signs,
+// control characters or invalid UTF-8.
+func appendLogfmtValue(b *bytes.Buffer, val string) {
+	if !logfmtNeedsQuotes(val) {
+		b.WriteString(val)
+		return
+	}
+
+	b.WriteByte('"')
+	for i := 0; i < len(val); {
+		c := val[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(val[i:])
+			if r == utf8.RuneError && size == 1 {
+				b.WriteString("\ufffd")
+			} else {
+				b.WriteString(val[i : i+size])
+			}
+			i += size
+			continue
+		}
+
+		appendLogfmtByte(b, c)
+		i++
+	}
+	b.WriteByte('"')
+}
+
+// appendLogfmtQuoted always quotes the value.
+func appendLogfmtQuoted(b *bytes.Buffer, val []byte) {
+	b.WriteByte('"')
+	for _, c := range val {
+		appendLogfmtByte(b, c)
+	}
+	b.WriteByte('"')
+}
+
+func appendLogfmtByte(b *bytes.Buffer, c byte) {
+	switch c {
+	case '\\', '"':
+		b.WriteByte('\\')
+		b.WriteByte(c)
+	case '\n':
+		b.WriteString(`\n`)
+	case '\r':
+		b.WriteString(`\r`)
+	case '\t':
+		b.WriteString(`\t`)
+	default:
+		if c < 0x20 || c == 0x7f {
+			b.WriteString(`\u00`)
+			b.WriteByte(_hex[c>>4])
+			b.WriteByte(_hex[c&0xF])
+			return
+		}
+		b.WriteByte(c)
+	}
+}
+
+func logfmtNeedsQuotes(val string) bool {
+	if val == "" {
+		return true
+	}
+
+	ascii := true
+	for i := 0; i < len(val); i++ {
+		c := val[i]
+		if c <= ' ' || c == '=' || c == '"' || c == '\\' || c == 0x7f {
+			return true
+		}
+		if c >= utf8.RuneSelf {
+			ascii = false
+		}
+	}
+	return !ascii && !utf8.ValidString(val)
+}
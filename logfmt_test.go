@@ -0,0 +1,89 @@
+package slog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLogfmtEncoder(t *testing.T) {
+	ogWriter, ogEncoder := Writer, DefaultEncoder
+	defer func() {
+		Writer, DefaultEncoder = ogWriter, ogEncoder
+		globalMu.Lock()
+		storeGlobals(nil)
+		globalMu.Unlock()
+	}()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	DefaultEncoder = NewLogfmtEncoder()
+	AddGlobalFields(String("app", "api"))
+
+	Info("user login",
+		Int("user", 42),
+		String("note", `said "hi"`+"\n"),
+		Bool("admin", false),
+		Float64("ratio", 0.5),
+		String("empty", ""),
+		String("bad key", "💩"),
+		Struct("profile", struct {
+			Name  string   `slog:"name"`
+			Roles []string `slog:"roles"`
+			Teams []struct {
+				ID int `slog:"id"`
+			} `slog:"teams"`
+		}{Name: "bob", Roles: []string{"a", "b"}, Teams: []struct {
+			ID int `slog:"id"`
+		}{{ID: 7}}}),
+	)
+
+	expected := `level=info msg="user login" user=42 note="said \"hi\"\n" admin=false ratio=0.5 empty="" bad_key=💩 ` +
+		`profile.name=bob profile.roles.0=a profile.roles.1=b profile.teams.0.id=7 app=api ts=`
+	if !strings.HasPrefix(b.String(), expected) {
+		t.Fatalf("unexpected output:\n%s\nexpected prefix:\n%s", b.String(), expected)
+	}
+}
+
+func TestSinkEncoder(t *testing.T) {
+	ogWriter := Writer
+	defer func() {
+		Writer = ogWriter
+		sinks.Store([]sink(nil))
+	}()
+
+	var primary, extra bytes.Buffer
+	Writer = traceSyncWrapper{&primary}
+	AddSink(traceSyncWrapper{&extra}, WithEncoder(NewLogfmtEncoder()))
+
+	Info("hello", Int("user", 42))
+
+	if !strings.HasPrefix(primary.String(), `{"level":"info", "msg":"hello", "user":42, `) {
+		t.Fatalf("unexpected default output: %s", primary.String())
+	}
+	if !strings.HasPrefix(extra.String(), `level=info msg=hello user=42 ts=`) {
+		t.Fatalf("unexpected sink output: %s", extra.String())
+	}
+}
+
+func BenchmarkLogfmt(b *testing.B) {
+	ogWriter, ogEncoder := Writer, DefaultEncoder
+	defer func() { Writer, DefaultEncoder = ogWriter, ogEncoder }()
+
+	Writer = DiscardWrapper
+	DefaultEncoder = NewLogfmtEncoder()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			Warning(
+				"fake",
+				String("str", "foo"),
+				Int("int", 1),
+				Int64("int64", 1),
+				String("string1", "\n"),
+				String("string2", "💩"),
+				Bool("bool", true),
+			)
+		}
+	})
+}
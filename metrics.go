@@ -0,0 +1,253 @@
+package slog
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Units understood by CloudWatch for `Metric` values.
+const (
+	UnitNone         = "None"
+	UnitCount        = "Count"
+	UnitPercent      = "Percent"
+	UnitSeconds      = "Seconds"
+	UnitMilliseconds = "Milliseconds"
+	UnitMicroseconds = "Microseconds"
+	UnitBytes        = "Bytes"
+	UnitKilobytes    = "Kilobytes"
+	UnitMegabytes    = "Megabytes"
+	UnitBytesPerSec  = "Bytes/Second"
+	UnitCountPerSec  = "Count/Second"
+)
+
+const (
+	// emfMaxValues is the most values CloudWatch accepts for a single metric in one entry.
+	emfMaxValues = 100
+
+	// emfMaxMetrics is the most metrics CloudWatch accepts in one entry.
+	emfMaxMetrics = 100
+)
+
+var (
+	// MetricsFlushInterval is how long values passed to `Metrics` are batched before they are written. Zero writes
+	// every call straight away.
+	MetricsFlushInterval = time.Minute
+
+	metricsMu    sync.Mutex
+	metricsTimer *time.Timer
+	batches      = map[string]*metricBatch{}
+)
+
+// Metric is a single value for `Metrics`, such as
+// `slog.Metric{Name: "latency", Value: 12, Unit: slog.UnitMilliseconds}`.
+type Metric struct {
+	Name  string
+	Value float64
+	Unit  string
+}
+
+// metricBatch holds the values for one namespace and set of dimensions until they are flushed.
+type metricBatch struct {
+	namespace string
+	dims      []string
+	dimValues map[string]string
+	start     time.Time
+	names     []string
+	units     map[string]string
+	values    map[string][]float64
+}
+
+// Metrics records values in the CloudWatch embedded metric format (EMF). Values with the same namespace and
+// dimensions are batched and written as a single entry every `MetricsFlushInterval`, or when `Sync` or
+// `FlushMetrics` is called. NaN and infinite values are dropped, since CloudWatch rejects them.
+//
+// EMF entries are JSON, so they are only written to `Writer` and the sinks whose encoder writes a JSON object per
+// line: the JSONEncoder and the encoders built on it, such as the ECSEncoder and GCPEncoder. Outputs with any
+// other encoder, such as framed CBOR, never see them.
+func Metrics(namespace string, dims map[string]string, values ...Metric) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	key := metricBatchKey(namespace, dims)
+	batch, ok := batches[key]
+	if !ok {
+		batch = newMetricBatch(namespace, dims)
+		batches[key] = batch
+	}
+
+	for _, m := range values {
+		if math.IsNaN(m.Value) || math.IsInf(m.Value, 0) {
+			continue
+		}
+
+		if _, ok := batch.values[m.Name]; !ok {
+			if len(batch.names) == emfMaxMetrics {
+				writeMetricBatch(batch)
+				batch = newMetricBatch(namespace, dims)
+				batches[key] = batch
+			}
+			batch.names = append(batch.names, m.Name)
+			batch.units[m.Name] = m.Unit
+		}
+		batch.values[m.Name] = append(batch.values[m.Name], m.Value)
+
+		if len(batch.values[m.Name]) == emfMaxValues {
+			writeMetricBatch(batch)
+			batch = newMetricBatch(namespace, dims)
+			batches[key] = batch
+		}
+	}
+
+	if MetricsFlushInterval <= 0 {
+		flushMetricsLocked()
+	} else if metricsTimer == nil {
+		metricsTimer = time.AfterFunc(MetricsFlushInterval, FlushMetrics)
+	}
+}
+
+// FlushMetrics writes every batched metric straight away.
+func FlushMetrics() {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	flushMetricsLocked()
+}
+
+func flushMetricsLocked() {
+	if metricsTimer != nil {
+		metricsTimer.Stop()
+		metricsTimer = nil
+	}
+
+	keys := make([]string, 0, len(batches))
+	for key := range batches {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		writeMetricBatch(batches[key])
+		delete(batches, key)
+	}
+}
+
+func newMetricBatch(namespace string, dims map[string]string) *metricBatch {
+	batch := &metricBatch{
+		namespace: namespace,
+		dimValues: make(map[string]string, len(dims)),
+		start:     time.Now(),
+		units:     map[string]string{},
+		values:    map[string][]float64{},
+	}
+	for name, val := range dims {
+		batch.dims = append(batch.dims, name)
+		batch.dimValues[name] = val
+	}
+	sort.Strings(batch.dims)
+	return batch
+}
+
+func metricBatchKey(namespace string, dims map[string]string) string {
+	names := make([]string, 0, len(dims))
+	for name := range dims {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var key strings.Builder
+	key.WriteString(namespace)
+	for _, name := range names {
+		key.WriteByte(0)
+		key.WriteString(name)
+		key.WriteByte(0)
+		key.WriteString(dims[name])
+	}
+	return key.String()
+}
+
+// writeMetricBatch writes the batch as a single EMF entry, such as
+// `{"_aws":{"Timestamp":1700000000000,"CloudWatchMetrics":[{"Namespace":"api","Dimensions":[["route"]],
+// "Metrics":[{"Name":"latency","Unit":"Milliseconds"}]}]},"route":"/users","latency":[12,15]}`.
+func writeMetricBatch(batch *metricBatch) {
+	if len(batch.names) == 0 {
+		return
+	}
+
+	bp := bufPool.get()
+	bp.WriteString(`{"_aws":{"Timestamp":`)
+	appendInt64Value(&bp.Buffer, batch.start.UnixNano()/int64(time.Millisecond))
+	bp.WriteString(`,"CloudWatchMetrics":[{"Namespace":`)
+	appendStrictStringValue(&bp.Buffer, batch.namespace)
+	bp.WriteString(`,"Dimensions":[[`)
+	for i, name := range batch.dims {
+		if i > 0 {
+			bp.WriteByte(',')
+		}
+		appendStrictStringValue(&bp.Buffer, name)
+	}
+	bp.WriteString(`]],"Metrics":[`)
+	for i, name := range batch.names {
+		if i > 0 {
+			bp.WriteByte(',')
+		}
+		bp.WriteString(`{"Name":`)
+		appendStrictStringValue(&bp.Buffer, name)
+		if unit := batch.units[name]; unit != "" {
+			bp.WriteString(`,"Unit":`)
+			appendStrictStringValue(&bp.Buffer, unit)
+		}
+		bp.WriteByte('}')
+	}
+	bp.WriteString(`]}]}`)
+
+	for _, name := range batch.dims {
+		bp.WriteByte(',')
+		appendStrictStringValue(&bp.Buffer, name)
+		bp.WriteByte(':')
+		appendStrictStringValue(&bp.Buffer, batch.dimValues[name])
+	}
+	for _, name := range batch.names {
+		bp.WriteByte(',')
+		appendStrictStringValue(&bp.Buffer, name)
+		bp.WriteString(`:[`)
+		for i, val := range batch.values[name] {
+			if i > 0 {
+				bp.WriteByte(',')
+			}
+			appendFloatValue(&bp.Buffer, val)
+		}
+		bp.WriteByte(']')
+	}
+	bp.WriteString("}\n")
+
+	mu.Lock()
+	if writesJSONLines(DefaultEncoder) {
+		_, _ = Writer.Write(bp.Bytes())
+	}
+	for _, s := range loadSinks() {
+		enc := s.enc
+		if enc == nil {
+			enc = DefaultEncoder
+		}
+		if writesJSONLines(enc) {
+			_, _ = s.ws.Write(bp.Bytes())
+		}
+	}
+	mu.Unlock()
+
+	bufPool.put(bp)
+}
+
+// jsonLinesEncoder is implemented by encoders whose output is a JSON object per line, which an EMF entry can be
+// mixed in with.
+type jsonLinesEncoder interface {
+	jsonLines()
+}
+
+func writesJSONLines(enc Encoder) bool {
+	_, ok := enc.(jsonLinesEncoder)
+	return ok
+}
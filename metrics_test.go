@@ -0,0 +1,116 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	ogWriter, ogInterval := Writer, MetricsFlushInterval
+	defer func() { Writer, MetricsFlushInterval = ogWriter, ogInterval }()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	MetricsFlushInterval = time.Hour
+
+	dims := map[string]string{"service": "api", "route": "/users"}
+	Metrics("shop", dims, Metric{Name: "latency", Value: 12, Unit: UnitMilliseconds}, Metric{Name: "hits", Value: 1, Unit: UnitCount})
+	Metrics("shop", map[string]string{"route": "/users", "service": "api"}, Metric{Name: "latency", Value: 15.5, Unit: UnitMilliseconds})
+	Metrics("shop", dims, Metric{Name: "latency", Value: math.NaN()})
+	if b.Len() != 0 {
+		t.Fatalf("expected the metrics to be batched: %s", b.String())
+	}
+
+	if err := Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected a single entry: %s", b.String())
+	}
+
+	expected := `"CloudWatchMetrics":[{"Namespace":"shop","Dimensions":[["route","service"]],` +
+		`"Metrics":[{"Name":"latency","Unit":"Milliseconds"},{"Name":"hits","Unit":"Count"}]}]},` +
+		`"route":"/users","service":"api","latency":[12,15.5],"hits":[1]}`
+	if !strings.HasSuffix(lines[0], expected) {
+		t.Fatalf("unexpected entry:\n%s\nexpected suffix:\n%s", lines[0], expected)
+	}
+
+	var entry struct {
+		AWS struct {
+			Timestamp int64
+		} `json:"_aws"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil || entry.AWS.Timestamp == 0 {
+		t.Fatalf("invalid entry %s: %v", lines[0], err)
+	}
+
+	b.Reset()
+	Metrics(`my "shop"`, map[string]string{"dir": `C:\tmp`}, Metric{Name: `a\b`, Value: 1})
+	FlushMetrics()
+
+	var escaped map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &escaped); err != nil {
+		t.Fatalf("invalid entry %s: %v", b.String(), err)
+	}
+	if escaped["dir"] != `C:\tmp` || escaped[`a\b`] == nil || !strings.Contains(b.String(), `"Namespace":"my \"shop\""`) {
+		t.Fatalf("unexpected entry: %s", b.String())
+	}
+}
+
+func TestMetricsLimits(t *testing.T) {
+	ogWriter, ogInterval := Writer, MetricsFlushInterval
+	defer func() { Writer, MetricsFlushInterval = ogWriter, ogInterval }()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	MetricsFlushInterval = time.Hour
+
+	for i := 0; i < emfMaxValues+1; i++ {
+		Metrics("shop", nil, Metric{Name: "hits", Value: 1})
+	}
+	if n := strings.Count(b.String(), "\n"); n != 1 {
+		t.Fatalf("expected a full batch to be written, got %d entries", n)
+	}
+
+	FlushMetrics()
+	if n := strings.Count(b.String(), "\n"); n != 2 {
+		t.Fatalf("expected the rest to be written, got %d entries", n)
+	}
+
+	b.Reset()
+	MetricsFlushInterval = 0
+	Metrics("shop", nil, Metric{Name: "hits", Value: 1})
+	if !strings.Contains(b.String(), `"Dimensions":[[]]`) {
+		t.Fatalf("expected an unbatched entry: %s", b.String())
+	}
+}
+
+func TestMetricsEncoders(t *testing.T) {
+	ogWriter, ogEncoder, ogInterval := Writer, DefaultEncoder, MetricsFlushInterval
+	defer func() {
+		Writer, DefaultEncoder, MetricsFlushInterval = ogWriter, ogEncoder, ogInterval
+		sinks.Store([]sink(nil))
+	}()
+
+	primary, plain, jsonSink := &recordingSyncer{}, &recordingSyncer{}, &recordingSyncer{}
+	Writer = primary
+	DefaultEncoder = NewCBOREncoder()
+	MetricsFlushInterval = 0
+	AddSink(plain)
+	AddSink(jsonSink, WithEncoder(NewECSEncoder()))
+
+	Metrics("shop", nil, Metric{Name: "hits", Value: 1})
+
+	if primary.Len() != 0 || plain.Len() != 0 {
+		t.Fatalf("expected no EMF in CBOR output: %q %q", primary.String(), plain.String())
+	}
+	if !strings.Contains(jsonSink.String(), `"hits":[1]`) {
+		t.Fatalf("expected EMF in the JSON sink: %q", jsonSink.String())
+	}
+}
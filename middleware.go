@@ -1,6 +1,9 @@
 package slog
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+)
 
 var (
 	// RequestHeaderKey is the key used when adding the header token.
@@ -18,6 +21,7 @@ func Requestify(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token := RequestToken.Generate()
 		r.Header.Add(RequestHeaderKey, token)
+		r = r.WithContext(WithRequestID(r.Context(), token))
 
 		if ResponseHeaderKey != "" {
 			w.Header().Add(ResponseHeaderKey, token)
@@ -25,3 +29,16 @@ func Requestify(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of the context carrying the request token.
+func WithRequestID(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, token)
+}
+
+// RequestID returns the request token carried by the context, if any.
+func RequestID(ctx context.Context) string {
+	token, _ := ctx.Value(requestIDKey{}).(string)
+	return token
+}
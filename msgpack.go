@@ -0,0 +1,467 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	msgpackNil     = 0xc0
+	msgpackFalse   = 0xc2
+	msgpackTrue    = 0xc3
+	msgpackFloat64 = 0xcb
+	msgpackArray32 = 0xdd
+	msgpackMap32   = 0xdf
+
+	// msgpackHeaderSize is the size of the map32 and array32 headers, whose length is filled in once the
+	// container is closed.
+	msgpackHeaderSize = 5
+)
+
+// MsgPackEncoder writes each entry as a MessagePack map. Maps and arrays are written with a 32 bit length that is
+// filled in when they are closed. Use MsgPackToJSON to read the entries back.
+type MsgPackEncoder struct {
+	// Framed prefixes each entry with its length, see ReadFrame.
+	Framed bool
+}
+
+// NewMsgPackEncoder returns an encoder for framed MessagePack output.
+func NewMsgPackEncoder() *MsgPackEncoder {
+	return &MsgPackEncoder{Framed: true}
+}
+
+func (m *MsgPackEncoder) cacheable() {}
+
+// BeginEntry implements Encoder.
+func (m *MsgPackEncoder) BeginEntry(b *Buffer, e *Entry) {
+	if m.Framed {
+		beginFrame(b)
+	}
+	m.open(b, msgpackMap32, "", false)
+
+	if !OmitLevel {
+		m.writeRawKey(b, SeverityKey)
+		if level, n, numeric := renderSeverity(e.Severity); numeric {
+			appendMsgPackInt(&b.Buffer, int64(n))
+		} else {
+			appendMsgPackString(&b.Buffer, level)
+		}
+	}
+
+	m.writeRawKey(b, TitleKey)
+	appendMsgPackString(&b.Buffer, e.Message)
+}
+
+// EndEntry implements Encoder.
+func (m *MsgPackEncoder) EndEntry(b *Buffer, e *Entry) {
+	if !OmitTime {
+		m.writeKey(b, TimeStampKey)
+		if len(TimeFormat) > 0 {
+			appendMsgPackString(&b.Buffer, e.Time.Format(TimeFormat))
+		} else {
+			appendMsgPackInt(&b.Buffer, e.Time.Unix())
+		}
+	}
+
+	s := m.close(b)
+	if m.Framed {
+		endFrame(b, s.Offset-msgpackHeaderSize-frameHeaderSize)
+	}
+}
+
+// AddBool implements Encoder.
+func (m *MsgPackEncoder) AddBool(b *Buffer, key string, val bool) {
+	m.writeKey(b, key)
+	if val {
+		b.WriteByte(msgpackTrue)
+	} else {
+		b.WriteByte(msgpackFalse)
+	}
+}
+
+// AddInt64 implements Encoder.
+func (m *MsgPackEncoder) AddInt64(b *Buffer, key string, val int64) {
+	m.writeKey(b, key)
+	appendMsgPackInt(&b.Buffer, val)
+}
+
+// AddUint64 implements Encoder.
+func (m *MsgPackEncoder) AddUint64(b *Buffer, key string, val uint64) {
+	m.writeKey(b, key)
+	appendMsgPackUint(&b.Buffer, val)
+}
+
+// AddFloat64 implements Encoder.
+func (m *MsgPackEncoder) AddFloat64(b *Buffer, key string, val float64) {
+	m.writeKey(b, key)
+	b.WriteByte(msgpackFloat64)
+	var bits [8]byte
+	binary.BigEndian.PutUint64(bits[:], math.Float64bits(val))
+	b.Write(bits[:])
+}
+
+// AddString implements Encoder.
+func (m *MsgPackEncoder) AddString(b *Buffer, key, val string) {
+	m.writeKey(b, key)
+	appendMsgPackString(&b.Buffer, val)
+}
+
+// AddJSONString implements Encoder.
+func (m *MsgPackEncoder) AddJSONString(b *Buffer, key, val string) {
+	m.AddString(b, key, val)
+}
+
+// AddRawJSON implements Encoder, converting the value into MessagePack.
+func (m *MsgPackEncoder) AddRawJSON(b *Buffer, key string, val []byte) {
+	encodeJSONValue(m, b, key, val)
+}
+
+// AddNull implements Encoder.
+func (m *MsgPackEncoder) AddNull(b *Buffer, key string) {
+	m.writeKey(b, key)
+	b.WriteByte(msgpackNil)
+}
+
+// BeginObject implements Encoder.
+func (m *MsgPackEncoder) BeginObject(b *Buffer, key string) {
+	m.writeKey(b, key)
+	m.open(b, msgpackMap32, key, false)
+}
+
+// EndObject implements Encoder.
+func (m *MsgPackEncoder) EndObject(b *Buffer) {
+	m.close(b)
+}
+
+// BeginArray implements Encoder.
+func (m *MsgPackEncoder) BeginArray(b *Buffer, key string) {
+	m.writeKey(b, key)
+	m.open(b, msgpackArray32, key, true)
+}
+
+// EndArray implements Encoder.
+func (m *MsgPackEncoder) EndArray(b *Buffer) {
+	m.close(b)
+}
+
+// open writes the header of a map or an array, leaving its length to be filled in by close.
+func (m *MsgPackEncoder) open(b *Buffer, header byte, key string, array bool) {
+	b.Write([]byte{header, 0, 0, 0, 0})
+	b.Push(key, array)
+}
+
+// close ends the innermost scope and fills in its length.
+func (m *MsgPackEncoder) close(b *Buffer) Scope {
+	s := b.Pop()
+	binary.BigEndian.PutUint32(b.Bytes()[s.Offset-4:], uint32(s.Count))
+	return s
+}
+
+// writeKey writes the key for the next value, leaving it out inside arrays.
+func (m *MsgPackEncoder) writeKey(b *Buffer, key string) {
+	top := b.Top()
+	top.Count++
+	if !top.Array {
+		appendMsgPackString(&b.Buffer, key)
+	}
+}
+
+// writeRawKey is writeKey for the built in keys.
+func (m *MsgPackEncoder) writeRawKey(b *Buffer, key []byte) {
+	b.Top().Count++
+	appendMsgPackStringHead(&b.Buffer, len(key))
+	b.Write(key)
+}
+
+func appendMsgPackUint(b *bytes.Buffer, val uint64) {
+	switch {
+	case val < 0x80:
+		b.WriteByte(byte(val))
+	case val <= math.MaxUint8:
+		b.WriteByte(0xcc)
+		b.WriteByte(byte(val))
+	case val <= math.MaxUint16:
+		b.WriteByte(0xcd)
+		b.WriteByte(byte(val >> 8))
+		b.WriteByte(byte(val))
+	case val <= math.MaxUint32:
+		var arg [5]byte
+		arg[0] = 0xce
+		binary.BigEndian.PutUint32(arg[1:], uint32(val))
+		b.Write(arg[:])
+	default:
+		var arg [9]byte
+		arg[0] = 0xcf
+		binary.BigEndian.PutUint64(arg[1:], val)
+		b.Write(arg[:])
+	}
+}
+
+func appendMsgPackInt(b *bytes.Buffer, val int64) {
+	switch {
+	case val >= 0:
+		appendMsgPackUint(b, uint64(val))
+	case val >= -32:
+		b.WriteByte(byte(val))
+	case val >= math.MinInt8:
+		b.WriteByte(0xd0)
+		b.WriteByte(byte(val))
+	case val >= math.MinInt16:
+		b.WriteByte(0xd1)
+		b.WriteByte(byte(val >> 8))
+		b.WriteByte(byte(val))
+	case val >= math.MinInt32:
+		var arg [5]byte
+		arg[0] = 0xd2
+		binary.BigEndian.PutUint32(arg[1:], uint32(val))
+		b.Write(arg[:])
+	default:
+		var arg [9]byte
+		arg[0] = 0xd3
+		binary.BigEndian.PutUint64(arg[1:], uint64(val))
+		b.Write(arg[:])
+	}
+}
+
+// appendMsgPackString writes a str, which has to be valid UTF-8.
+func appendMsgPackString(b *bytes.Buffer, val string) {
+	if !utf8.ValidString(val) {
+		val = strings.ToValidUTF8(val, "\ufffd")
+	}
+	appendMsgPackStringHead(b, len(val))
+	b.WriteString(val)
+}
+
+func appendMsgPackStringHead(b *bytes.Buffer, n int) {
+	switch {
+	case n < 32:
+		b.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		b.WriteByte(0xd9)
+		b.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		b.WriteByte(0xda)
+		b.WriteByte(byte(n >> 8))
+		b.WriteByte(byte(n))
+	default:
+		var arg [5]byte
+		arg[0] = 0xdb
+		binary.BigEndian.PutUint32(arg[1:], uint32(n))
+		b.Write(arg[:])
+	}
+}
+
+// MsgPackToJSON converts a single MessagePack value, such as an entry written by MsgPackEncoder, into JSON. Map
+// keys keep their order, while bin and ext values become base64 strings.
+func MsgPackToJSON(data []byte) ([]byte, error) {
+	d := &msgpackDecoder{data: data}
+	var out bytes.Buffer
+	if err := d.value(&out, 0); err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("slog: %d bytes of trailing data after MessagePack value", len(d.data)-d.pos)
+	}
+	return out.Bytes(), nil
+}
+
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) value(out *bytes.Buffer, depth int) error {
+	if depth > maxDecodeDepth {
+		return errors.New("slog: MessagePack value is nested too deeply")
+	}
+
+	c, err := d.byte()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case c < 0x80:
+		appendInt64Value(out, int64(c))
+		return nil
+	case c >= 0xe0:
+		appendInt64Value(out, int64(int8(c)))
+		return nil
+	case c&0xf0 == 0x80:
+		return d.container(out, int(c&0x0f), depth, true)
+	case c&0xf0 == 0x90:
+		return d.container(out, int(c&0x0f), depth, false)
+	case c&0xe0 == 0xa0:
+		return d.str(out, int(c&0x1f))
+	}
+
+	switch c {
+	case msgpackNil:
+		out.WriteString("null")
+	case msgpackFalse:
+		out.WriteString("false")
+	case msgpackTrue:
+		out.WriteString("true")
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uint(1 << (c - 0xc4))
+		if err != nil {
+			return err
+		}
+		return d.base64(out, n)
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.uint(1 << (c - 0xc7))
+		if err != nil {
+			return err
+		}
+		return d.ext(out, n)
+	case 0xca:
+		bits, err := d.uint(4)
+		if err != nil {
+			return err
+		}
+		appendStrictFloatValue(out, float64(math.Float32frombits(uint32(bits))))
+	case msgpackFloat64:
+		bits, err := d.uint(8)
+		if err != nil {
+			return err
+		}
+		appendStrictFloatValue(out, math.Float64frombits(bits))
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := d.uint(1 << (c - 0xcc))
+		if err != nil {
+			return err
+		}
+		appendUint64Value(out, n)
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		n, err := d.uint(size)
+		if err != nil {
+			return err
+		}
+		// Sign extend from the size that was read.
+		shift := uint(64 - size*8)
+		appendInt64Value(out, int64(n<<shift)>>shift)
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(out, 1<<(c-0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (c - 0xd9))
+		if err != nil {
+			return err
+		}
+		return d.str(out, int(n))
+	case 0xdc, msgpackArray32:
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return err
+		}
+		return d.container(out, int(n), depth, false)
+	case 0xde, msgpackMap32:
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return err
+		}
+		return d.container(out, int(n), depth, true)
+	default:
+		return fmt.Errorf("slog: invalid MessagePack type 0x%x", c)
+	}
+	return nil
+}
+
+func (d *msgpackDecoder) container(out *bytes.Buffer, n, depth int, isMap bool) error {
+	if n > len(d.data)-d.pos {
+		return errTruncatedInput
+	}
+
+	open, end := byte('['), byte(']')
+	if isMap {
+		open, end = '{', '}'
+	}
+
+	out.WriteByte(open)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+
+		if isMap {
+			var key bytes.Buffer
+			if err := d.value(&key, depth+1); err != nil {
+				return err
+			}
+			appendDecodedKey(out, key.Bytes())
+		}
+		if err := d.value(out, depth+1); err != nil {
+			return err
+		}
+	}
+	out.WriteByte(end)
+	return nil
+}
+
+func (d *msgpackDecoder) str(out *bytes.Buffer, n int) error {
+	s, err := d.next(n)
+	if err != nil {
+		return err
+	}
+	appendDecodedString(out, s)
+	return nil
+}
+
+// ext writes an extension value as a base64 string, leaving out its type.
+func (d *msgpackDecoder) ext(out *bytes.Buffer, n uint64) error {
+	if _, err := d.byte(); err != nil {
+		return err
+	}
+	return d.base64(out, n)
+}
+
+func (d *msgpackDecoder) base64(out *bytes.Buffer, n uint64) error {
+	if n > uint64(len(d.data)-d.pos) {
+		return errTruncatedInput
+	}
+	s, err := d.next(int(n))
+	if err != nil {
+		return err
+	}
+
+	out.WriteByte('"')
+	out.WriteString(base64.StdEncoding.EncodeToString(s))
+	out.WriteByte('"')
+	return nil
+}
+
+// uint reads a big endian unsigned integer of size bytes.
+func (d *msgpackDecoder) uint(size int) (uint64, error) {
+	arg, err := d.next(size)
+	if err != nil {
+		return 0, err
+	}
+
+	var n uint64
+	for _, c := range arg {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+func (d *msgpackDecoder) byte() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, errTruncatedInput
+	}
+	d.pos++
+	return d.data[d.pos-1], nil
+}
+
+func (d *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, errTruncatedInput
+	}
+	d.pos += n
+	return d.data[d.pos-n : d.pos], nil
+}
@@ -0,0 +1,47 @@
+package slog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMsgPackEncoder(t *testing.T) {
+	entries := binaryEntries(t, NewMsgPackEncoder(), MsgPackToJSON)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	expected := `{"level":"info","msg":"first","n":-1000,"u":1099511627776,"f":0.5,"ok":true,"s":"a \"quote\"",` +
+		`"raw":{"a":[1,-2.5,null,"x"]},"st":{"tags":["a"]},"app":"api","ts":`
+	if !strings.HasPrefix(entries[0], expected) {
+		t.Fatalf("unexpected entry:\n%s\nexpected prefix:\n%s", entries[0], expected)
+	}
+	if !strings.HasPrefix(entries[1], `{"level":"warn","msg":"second","app":"api","ts":`) {
+		t.Fatalf("unexpected entry: %s", entries[1])
+	}
+}
+
+func TestMsgPackToJSON(t *testing.T) {
+	tests := map[string]string{
+		"\x93\x01\xff\xd1\x80\x00":             `[1,-1,-32768]`,
+		"\x81\x01\xc4\x02\x01\x02":             `{"1":"AQI="}`,
+		"\xd3\x80\x00\x00\x00\x00\x00\x00\x00": `-9223372036854775808`,
+		"\xd9\x03abc":                          `"abc"`,
+		"\x92\xca\xff\x80\x00\x00\xcb\x7f\xf8\x00\x00\x00\x00\x00\x00": `["-Inf","NaN"]`,
+	}
+	for in, expected := range tests {
+		out, err := MsgPackToJSON([]byte(in))
+		if err != nil {
+			t.Fatalf("%x: %v", in, err)
+		}
+		if string(out) != expected {
+			t.Fatalf("%x: expected %s, got %s", in, expected, out)
+		}
+	}
+
+	for _, in := range []string{"", "\x92\x01", "\xdf\xff\xff\xff\xff", "\xc1", "\x01\x02"} {
+		if _, err := MsgPackToJSON([]byte(in)); err == nil {
+			t.Fatalf("%x: expected an error", in)
+		}
+	}
+}
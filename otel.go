@@ -0,0 +1,278 @@
+package slog
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// OTelEncoder writes each entry as a log record of the OpenTelemetry logs data model, in the OTLP JSON form:
+// `timeUnixNano`, `severityNumber`, `severityText`, `body`, `attributes`, and the `traceId` and `spanId` of any
+// `TraceParent` field or of a `Request` carrying a `traceparent` header. Errors, stacks and trace locations become
+// the `exception.*` and `code.*` attributes. Use it with an OTLPExporter to send the records to a collector.
+type OTelEncoder struct {
+	// Resource adds a `resource` with these attributes to every record, for collectors reading the records from a
+	// file. Leave it empty when exporting with an OTLPExporter, which sends its own resource.
+	Resource []Field
+}
+
+// NewOTelEncoder returns an encoder for OpenTelemetry log records.
+func NewOTelEncoder(resource ...Field) *OTelEncoder {
+	return &OTelEncoder{Resource: resource}
+}
+
+// BeginEntry implements Encoder.
+func (o *OTelEncoder) BeginEntry(b *Buffer, e *Entry) {
+	number, text := otelSeverity(e.Severity)
+
+	b.WriteString(`{"timeUnixNano":"`)
+	appendInt64Value(&b.Buffer, e.Time.UnixNano())
+	b.WriteString(`","severityNumber":`)
+	appendInt64Value(&b.Buffer, int64(number))
+	b.WriteString(`,"severityText":`)
+	appendStrictStringValue(&b.Buffer, text)
+	b.WriteString(`,"body":{"stringValue":`)
+	appendStrictStringValue(&b.Buffer, e.Message)
+	b.WriteString(`},"attributes":[`)
+	b.Push("", false)
+}
+
+// EndEntry implements Encoder.
+func (o *OTelEncoder) EndEntry(b *Buffer, e *Entry) {
+	b.Pop()
+	b.WriteByte(']')
+
+	var trace *traceContext
+	for _, f := range b.held {
+		switch f.fieldType {
+		case traceType:
+			trace = f.obj.(*traceContext)
+		case requestType:
+			if tc, ok := parseTraceParent(f.obj.(*http.Request).Header.Get(TraceParentHeader)); ok && trace == nil {
+				trace = tc
+			}
+		}
+	}
+	b.release()
+
+	if trace != nil {
+		b.WriteString(`,"traceId":"`)
+		b.WriteString(trace.traceID)
+		b.WriteString(`","spanId":"`)
+		b.WriteString(trace.spanID)
+		b.WriteString(`","flags":`)
+		if trace.sampled {
+			b.WriteByte('1')
+		} else {
+			b.WriteByte('0')
+		}
+	}
+
+	if len(o.Resource) > 0 {
+		b.WriteString(`,"resource":{"attributes":`)
+		o.appendAttributes(b, o.Resource)
+		b.WriteByte('}')
+	}
+
+	b.WriteByte('}')
+	b.WriteByte('\n')
+}
+
+// encodeHeld implements heldEncoder, writing a single trace location with its semantic convention names. A
+// `TraceErr` location is preferred over the caller.
+func (o *OTelEncoder) encodeHeld(b *Buffer, e *Entry, limit int) int {
+	var origin *Field
+	for i := range b.held {
+		f := &b.held[i]
+		switch f.fieldType {
+		case originType:
+			origin = f
+		case callerType:
+			if origin == nil || origin.fieldType == callerType {
+				origin = f
+			}
+		}
+	}
+	if origin == nil {
+		return 0
+	}
+
+	ci := origin.obj.(*callerInfo)
+	return limitWrite(b, limit, func() {
+		o.AddString(b, "code.filepath", ci.file)
+		o.AddInt64(b, "code.lineno", int64(ci.line))
+		o.AddString(b, "code.function", ci.function)
+	})
+}
+
+// appendAttributes writes the fields as a list of attributes.
+func (o *OTelEncoder) appendAttributes(b *Buffer, fields []Field) {
+	b.WriteByte('[')
+	b.Push("", false)
+	for _, f := range fields {
+		f.encode(o, b)
+	}
+	b.Pop()
+	b.WriteByte(']')
+}
+
+// encodeSemantic implements semanticEncoder, writing errors and stacks with their semantic convention names, and
+// holding back trace locations and the fields that carry a trace.
+func (o *OTelEncoder) encodeSemantic(b *Buffer, f Field) bool {
+	switch f.fieldType {
+	case errorType:
+		o.AddString(b, "exception.message", b.truncate(f.obj.(error).Error(), MaxStringLength))
+	case stackType:
+		o.AddString(b, "exception.stacktrace", formatStack(f.obj.([]stackFrame)))
+	case originType, callerType:
+		b.held = append(b.held, f)
+	case requestType:
+		if f.str != "" {
+			o.AddString(b, f.key, f.str)
+		}
+		b.held = append(b.held, f)
+	case traceType:
+		b.held = append(b.held, f)
+	default:
+		return false
+	}
+	return true
+}
+
+// AddBool implements Encoder.
+func (o *OTelEncoder) AddBool(b *Buffer, key string, val bool) {
+	o.beginValue(b, key)
+	if val {
+		b.WriteString(`{"boolValue":true}`)
+	} else {
+		b.WriteString(`{"boolValue":false}`)
+	}
+	o.endValue(b)
+}
+
+// AddInt64 implements Encoder. OTLP JSON writes 64 bit integers as strings.
+func (o *OTelEncoder) AddInt64(b *Buffer, key string, val int64) {
+	o.beginValue(b, key)
+	b.WriteString(`{"intValue":"`)
+	appendInt64Value(&b.Buffer, val)
+	b.WriteString(`"}`)
+	o.endValue(b)
+}
+
+// AddUint64 implements Encoder. Values too large for an int64 are written as doubles.
+func (o *OTelEncoder) AddUint64(b *Buffer, key string, val uint64) {
+	if val > math.MaxInt64 {
+		o.AddFloat64(b, key, float64(val))
+		return
+	}
+	o.AddInt64(b, key, int64(val))
+}
+
+// AddFloat64 implements Encoder.
+func (o *OTelEncoder) AddFloat64(b *Buffer, key string, val float64) {
+	o.beginValue(b, key)
+	b.WriteString(`{"doubleValue":`)
+	if math.IsNaN(val) || math.IsInf(val, 0) {
+		// JSON has no such numbers, OTLP writes them as strings.
+		b.WriteByte('"')
+		appendFloatValue(&b.Buffer, val)
+		b.WriteByte('"')
+	} else {
+		b.Write(strconv.AppendFloat(make([]byte, 0, initialFloatSize), val, 'g', -1, 64))
+	}
+	b.WriteByte('}')
+	o.endValue(b)
+}
+
+// AddString implements Encoder.
+func (o *OTelEncoder) AddString(b *Buffer, key, val string) {
+	o.beginValue(b, key)
+	b.WriteString(`{"stringValue":`)
+	appendStrictStringValue(&b.Buffer, val)
+	b.WriteByte('}')
+	o.endValue(b)
+}
+
+// AddJSONString implements Encoder.
+func (o *OTelEncoder) AddJSONString(b *Buffer, key, val string) {
+	o.AddString(b, key, val)
+}
+
+// AddRawJSON implements Encoder, converting the value into attribute values.
+func (o *OTelEncoder) AddRawJSON(b *Buffer, key string, val []byte) {
+	encodeJSONValue(o, b, key, val)
+}
+
+// AddNull implements Encoder, writing an empty value.
+func (o *OTelEncoder) AddNull(b *Buffer, key string) {
+	o.beginValue(b, key)
+	b.WriteString(`{}`)
+	o.endValue(b)
+}
+
+// BeginObject implements Encoder.
+func (o *OTelEncoder) BeginObject(b *Buffer, key string) {
+	o.beginValue(b, key)
+	b.WriteString(`{"kvlistValue":{"values":[`)
+	b.Push(key, false)
+}
+
+// EndObject implements Encoder.
+func (o *OTelEncoder) EndObject(b *Buffer) {
+	b.Pop()
+	b.WriteString(`]}}`)
+	o.endValue(b)
+}
+
+// BeginArray implements Encoder.
+func (o *OTelEncoder) BeginArray(b *Buffer, key string) {
+	o.beginValue(b, key)
+	b.WriteString(`{"arrayValue":{"values":[`)
+	b.Push(key, true)
+}
+
+// EndArray implements Encoder.
+func (o *OTelEncoder) EndArray(b *Buffer) {
+	b.Pop()
+	b.WriteString(`]}}`)
+	o.endValue(b)
+}
+
+// beginValue writes the separator for the next value, and opens a key value pair for it outside of arrays.
+func (o *OTelEncoder) beginValue(b *Buffer, key string) {
+	top := b.Top()
+	if top.Count > 0 {
+		b.WriteByte(',')
+	}
+	top.Count++
+
+	if !top.Array {
+		b.WriteString(`{"key":`)
+		appendStrictStringValue(&b.Buffer, key)
+		b.WriteString(`,"value":`)
+	}
+}
+
+// endValue closes the key value pair opened by beginValue.
+func (o *OTelEncoder) endValue(b *Buffer) {
+	if !b.Top().Array {
+		b.WriteByte('}')
+	}
+}
+
+// otelSeverity maps a severity onto the OpenTelemetry severity numbers and texts.
+func otelSeverity(severity string) (int, string) {
+	switch severity {
+	case SeverityDebug:
+		return 5, "DEBUG"
+	case SeverityInfo:
+		return 9, "INFO"
+	case SeverityWarn:
+		return 13, "WARN"
+	case SeverityError:
+		return 17, "ERROR"
+	case SeverityPanic, SeverityFatal:
+		return 21, "FATAL"
+	}
+	return 0, severity
+}
@@ -0,0 +1,204 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOTelEncoder(t *testing.T) {
+	ogWriter, ogEncoder := Writer, DefaultEncoder
+	defer func() { Writer, DefaultEncoder = ogWriter, ogEncoder }()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	DefaultEncoder = NewOTelEncoder(String("service.name", "api"))
+
+	Error("failed", Err(errors.New("boom")), Int("n", 7), Float64("f", 1.5), Bool("ok", false),
+		TraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"),
+		Struct("user", struct {
+			Tags []string `slog:"tags"`
+		}{Tags: []string{"a"}}))
+
+	expected := `"severityNumber":17,"severityText":"ERROR","body":{"stringValue":"failed"},"attributes":[` +
+		`{"key":"exception.message","value":{"stringValue":"boom"}},{"key":"n","value":{"intValue":"7"}},` +
+		`{"key":"f","value":{"doubleValue":1.5}},{"key":"ok","value":{"boolValue":false}},` +
+		`{"key":"user","value":{"kvlistValue":{"values":[` +
+		`{"key":"tags","value":{"arrayValue":{"values":[{"stringValue":"a"}]}}}]}}}],` +
+		`"traceId":"4bf92f3577b34da6a3ce929d0e0e4736","spanId":"00f067aa0ba902b7","flags":1,` +
+		`"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"api"}}]}}` + "\n"
+	if !strings.HasPrefix(b.String(), `{"timeUnixNano":"`) || !strings.HasSuffix(b.String(), expected) {
+		t.Fatalf("unexpected output:\n%s\nexpected suffix:\n%s", b.String(), expected)
+	}
+	if !json.Valid(b.Bytes()) {
+		t.Fatalf("invalid output: %s", b.String())
+	}
+
+	b.Reset()
+	Info(`say "hi"`, String(`C:\tmp`, `a\b`))
+
+	expected = `"body":{"stringValue":"say \"hi\""},"attributes":[{"key":"C:\\tmp","value":{"stringValue":"a\\b"}}]`
+	if !strings.Contains(b.String(), expected) || !json.Valid(b.Bytes()) {
+		t.Fatalf("unexpected output:\n%s\nexpected:\n%s", b.String(), expected)
+	}
+
+	// The origin of a TraceErr is written instead of the caller, never alongside it.
+	ogCaller := EnableCaller
+	defer func() { EnableCaller = ogCaller }()
+	EnableCaller = true
+
+	b.Reset()
+	_ = TraceErr(errors.New("boom"))
+
+	if strings.Count(b.String(), `"code.filepath"`) != 1 || strings.Count(b.String(), `"code.lineno"`) != 1 ||
+		!strings.Contains(b.String(), `otel_test.go"}},{"key":"code.lineno"`) {
+		t.Fatalf("expected a single trace location: %s", b.String())
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	attempts := 0
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+	}))
+	defer collector.Close()
+
+	exporter := NewOTLPExporter(collector.URL+"/v1/logs",
+		WithOTLPHeaders(map[string]string{"X-Api-Key": "secret"}),
+		WithOTLPResource(String("service.name", "api")),
+		WithOTLPBatch(10, time.Hour),
+		WithOTLPRetry(3, time.Millisecond),
+	)
+
+	_, _ = exporter.Write([]byte(`{"body":{"stringValue":"one"}}` + "\n"))
+	_, _ = exporter.Write([]byte(`{"body":{"stringValue":"two"}}` + "\n"))
+	if err := exporter.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	expected := `{"resourceLogs":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"api"}}]},` +
+		`"scopeLogs":[{"scope":{"name":"github.com/unrolled/slog"},"logRecords":[` +
+		`{"body":{"stringValue":"one"}},{"body":{"stringValue":"two"}}]}]}]}`
+	if attempts != 2 || len(bodies) != 1 || bodies[0] != expected {
+		t.Fatalf("unexpected exports after %d attempts: %v", attempts, bodies)
+	}
+}
+
+func TestOTLPExporterFailure(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer collector.Close()
+
+	exporter := NewOTLPExporter(collector.URL, WithOTLPRetry(3, time.Millisecond))
+	_, _ = exporter.Write([]byte(`{}`))
+	if err := exporter.Sync(); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Fatalf("expected the export to fail without retrying: %v", err)
+	}
+}
+
+func TestOTLPExporterQueue(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	exports := 0
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		mu.Lock()
+		exports++
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	exporter := NewOTLPExporter(collector.URL, WithOTLPBatch(1, time.Hour), WithOTLPQueue(2))
+	for i := 0; i < 10; i++ {
+		_, _ = exporter.Write([]byte(`{"body":{"stringValue":"` + strconv.Itoa(i) + `"}}`))
+	}
+	close(release)
+	if err := exporter.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// One batch may be with the collector while two wait, so at least seven are dropped.
+	if exports > 3 || exporter.Dropped() < 7 || uint64(exports)+exporter.Dropped() != 10 {
+		t.Fatalf("unexpected %d exports with %d dropped", exports, exporter.Dropped())
+	}
+}
+
+func TestOTLPExporterSyncWaits(t *testing.T) {
+	var mu sync.Mutex
+	var records []string
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+
+		var req struct {
+			ResourceLogs []struct {
+				ScopeLogs []struct {
+					LogRecords []struct {
+						Body struct {
+							StringValue string `json:"stringValue"`
+						} `json:"body"`
+					} `json:"logRecords"`
+				} `json:"scopeLogs"`
+			} `json:"resourceLogs"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, record := range req.ResourceLogs[0].ScopeLogs[0].LogRecords {
+			records = append(records, record.Body.StringValue)
+		}
+	}))
+	defer collector.Close()
+
+	exporter := NewOTLPExporter(collector.URL, WithOTLPBatch(2, time.Hour))
+	for i := 0; i < 10; i++ {
+		_, _ = exporter.Write([]byte(`{"body":{"stringValue":"` + strconv.Itoa(i) + `"}}`))
+	}
+	if err := exporter.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if strings.Join(records, ",") != "0,1,2,3,4,5,6,7,8,9" {
+		t.Fatalf("expected every record in order once Sync returns: %v", records)
+	}
+}
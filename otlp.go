@@ -0,0 +1,304 @@
+package slog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	otlpBatchSize     = 512
+	otlpFlushInterval = 5 * time.Second
+	otlpMaxQueue      = 64
+	otlpMaxRetries    = 5
+	otlpRetryBackoff  = 500 * time.Millisecond
+	otlpMaxBackoff    = 30 * time.Second
+	otlpScopeName     = "github.com/unrolled/slog"
+)
+
+// An OTLPOption configures an OTLPExporter.
+type OTLPOption func(*OTLPExporter)
+
+// WithOTLPHeaders adds headers, such as an API key, to every export request.
+func WithOTLPHeaders(headers map[string]string) OTLPOption {
+	return func(e *OTLPExporter) {
+		for key, val := range headers {
+			e.headers.Set(key, val)
+		}
+	}
+}
+
+// WithOTLPResource sets the resource the records are exported under, such as `String("service.name", "api")`.
+func WithOTLPResource(fields ...Field) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.resource = fields
+	}
+}
+
+// WithOTLPBatch sets how many records are exported at once, and how long a record may wait for a batch to fill.
+func WithOTLPBatch(size int, interval time.Duration) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.batchSize = size
+		e.interval = interval
+	}
+}
+
+// WithOTLPQueue sets how many full batches may wait for export. Once that many are waiting, such as while the
+// collector is unreachable, the oldest is dropped to make room.
+func WithOTLPQueue(size int) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.maxQueue = size
+	}
+}
+
+// WithOTLPRetry sets how many times a failed export is retried, and the backoff before the first retry, which
+// doubles for every retry after it.
+func WithOTLPRetry(retries int, backoff time.Duration) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.retries = retries
+		e.backoff = backoff
+	}
+}
+
+// WithOTLPClient sends the export requests with client instead of a client with a 10 second timeout.
+func WithOTLPClient(client *http.Client) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.client = client
+	}
+}
+
+// OTLPExporter is a WriteSyncer that sends the records written by an OTelEncoder to an OpenTelemetry collector
+// over OTLP/HTTP with JSON encoding. Records are exported in batches, in order, from a background goroutine once a
+// batch fills or its interval passes. `Sync` exports what is left and waits for every batch before it. Failed
+// exports are retried with an exponential backoff. Every call to Write is a single record.
+type OTLPExporter struct {
+	endpoint  string
+	client    *http.Client
+	headers   http.Header
+	resource  []Field
+	batchSize int
+	interval  time.Duration
+	retries   int
+	backoff   time.Duration
+	maxQueue  int
+	dropped   uint64
+
+	mu       sync.Mutex
+	changed  *sync.Cond
+	pending  [][]byte
+	timer    *time.Timer
+	batches  [][][]byte // full batches waiting for the worker, oldest first
+	queued   uint64     // batches handed to the worker
+	finished uint64     // batches the worker is done with, exported or not
+	running  bool
+	err      error
+}
+
+// NewOTLPExporter returns an exporter posting to the logs endpoint of a collector, such as
+// `http://localhost:4318/v1/logs`.
+func NewOTLPExporter(endpoint string, opts ...OTLPOption) *OTLPExporter {
+	e := &OTLPExporter{
+		endpoint:  endpoint,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		headers:   http.Header{},
+		batchSize: otlpBatchSize,
+		interval:  otlpFlushInterval,
+		retries:   otlpMaxRetries,
+		backoff:   otlpRetryBackoff,
+		maxQueue:  otlpMaxQueue,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.batchSize < 1 {
+		e.batchSize = 1
+	}
+	if e.maxQueue < 1 {
+		e.maxQueue = 1
+	}
+	e.changed = sync.NewCond(&e.mu)
+	return e
+}
+
+// Write queues a record for the next export.
+func (e *OTLPExporter) Write(p []byte) (int, error) {
+	record := make([]byte, len(bytes.TrimRight(p, "\n")))
+	copy(record, p)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.pending = append(e.pending, record)
+	if len(e.pending) >= e.batchSize {
+		e.flushLocked()
+	} else if e.timer == nil && e.interval > 0 {
+		e.timer = time.AfterFunc(e.interval, e.flush)
+	}
+	return len(p), nil
+}
+
+// Sync exports every queued record, returning once it and every batch before it are done. It returns the first
+// export error since the last Sync.
+func (e *OTLPExporter) Sync() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.flushLocked()
+	for target := e.queued; e.finished < target; {
+		e.changed.Wait()
+	}
+	err := e.err
+	e.err = nil
+	return err
+}
+
+// Close exports every queued record.
+func (e *OTLPExporter) Close() error {
+	return e.Sync()
+}
+
+// Dropped returns the number of batches dropped because the queue was full.
+func (e *OTLPExporter) Dropped() uint64 {
+	return atomic.LoadUint64(&e.dropped)
+}
+
+// flush hands the queued records to the worker once the batch interval passes.
+func (e *OTLPExporter) flush() {
+	e.mu.Lock()
+	e.flushLocked()
+	e.mu.Unlock()
+}
+
+// flushLocked hands the queued records to the worker as a batch, dropping the oldest batch if the queue is full,
+// starting the worker if it is not running, and stops the timer. Callers must hold mu.
+func (e *OTLPExporter) flushLocked() {
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+	if len(e.pending) == 0 {
+		return
+	}
+
+	if len(e.batches) == e.maxQueue {
+		e.batches[0] = nil
+		e.batches = e.batches[1:]
+		e.finished++
+		atomic.AddUint64(&e.dropped, 1)
+	}
+	e.batches = append(e.batches, e.pending)
+	e.pending = nil
+	e.queued++
+	if !e.running {
+		e.running = true
+		go e.run()
+	}
+}
+
+// run exports the batches one at a time, so they reach the collector in order, and exits once none are left.
+func (e *OTLPExporter) run() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for len(e.batches) > 0 {
+		batch := e.batches[0]
+		e.batches[0] = nil
+		e.batches = e.batches[1:]
+
+		e.mu.Unlock()
+		err := e.export(batch)
+		e.mu.Lock()
+
+		if err != nil && e.err == nil {
+			e.err = err
+		}
+		e.finished++
+		e.changed.Broadcast()
+	}
+	e.running = false
+}
+
+// export posts the records, retrying failures that may pass on a later attempt.
+func (e *OTLPExporter) export(batch [][]byte) error {
+	body := e.body(batch)
+	backoff := e.backoff
+	for attempt := 0; ; attempt++ {
+		retry, wait, err := e.post(body)
+		if err == nil || !retry || attempt >= e.retries {
+			return err
+		}
+
+		if wait <= 0 {
+			wait = backoff
+			if backoff *= 2; backoff > otlpMaxBackoff {
+				backoff = otlpMaxBackoff
+			}
+		}
+		time.Sleep(wait)
+	}
+}
+
+// body wraps the records in an export request for the resource.
+func (e *OTLPExporter) body(batch [][]byte) []byte {
+	bp := bufPool.get()
+	defer bufPool.put(bp)
+
+	bp.WriteString(`{"resourceLogs":[{"resource":{"attributes":`)
+	(&OTelEncoder{}).appendAttributes(bp, e.resource)
+	bp.WriteString(`},"scopeLogs":[{"scope":{"name":"` + otlpScopeName + `"},"logRecords":[`)
+	for i, record := range batch {
+		if i > 0 {
+			bp.WriteByte(',')
+		}
+		bp.Write(record)
+	}
+	bp.WriteString(`]}]}]}`)
+
+	body := make([]byte, bp.Len())
+	copy(body, bp.Bytes())
+	return body
+}
+
+// post sends one export request. It reports whether a failure is worth retrying, and how long the collector
+// asked to wait before doing so.
+func (e *OTLPExporter) post(body []byte) (bool, time.Duration, error) {
+	req, err := http.NewRequest("POST", e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, 0, err
+	}
+	for key, vals := range e.headers {
+		req.Header[key] = vals
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return true, 0, err
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, 0, nil
+	}
+
+	err = fmt.Errorf("slog: OTLP export failed with status %d", resp.StatusCode)
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		wait := time.Duration(0)
+		if secs, perr := strconv.Atoi(resp.Header.Get("Retry-After")); perr == nil && secs > 0 {
+			wait = time.Duration(secs) * time.Second
+			if wait > otlpMaxBackoff {
+				wait = otlpMaxBackoff
+			}
+		}
+		return true, wait, err
+	}
+	return false, 0, err
+}
@@ -0,0 +1,134 @@
+package slog
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// RedactedValue is written in place of a `Hashed` or `Encrypted` value when no key has been configured.
+const RedactedValue = "[REDACTED]"
+
+var (
+	privacyMu sync.RWMutex
+
+	hashKeyID string
+	hashKey   []byte
+
+	encryptionKeyID string
+	encryptionAEAD  cipher.AEAD
+
+	errUnknownKeyID = errors.New("slog: unknown encryption key id")
+	errMalformed    = errors.New("slog: malformed encrypted value")
+)
+
+// SetHashKey sets the HMAC secret used by `Hashed`. The id is included in the output so keys can be rotated.
+func SetHashKey(id string, secret []byte) {
+	privacyMu.Lock()
+	defer privacyMu.Unlock()
+
+	hashKeyID = id
+	hashKey = append([]byte(nil), secret...)
+}
+
+// SetEncryptionKey sets the AES key (16, 24 or 32 bytes) used by `Encrypted`. The id is included in the
+// output so the matching key can be found when decrypting.
+func SetEncryptionKey(id string, secret []byte) error {
+	aead, err := newAEAD(secret)
+	if err != nil {
+		return err
+	}
+
+	privacyMu.Lock()
+	defer privacyMu.Unlock()
+
+	encryptionKeyID = id
+	encryptionAEAD = aead
+	return nil
+}
+
+// Hashed outputs a keyed HMAC-SHA256 of the value as `<key id>:<hex digest>`, so entries stay joinable without
+// containing the raw value.
+func Hashed(key string, val string) Field {
+	privacyMu.RLock()
+	id, secret := hashKeyID, hashKey
+	privacyMu.RUnlock()
+
+	if secret == nil {
+		return String(key, RedactedValue)
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(val))
+	return exactString(key, id+":"+hex.EncodeToString(mac.Sum(nil)))
+}
+
+// Encrypted outputs the value sealed with AES-GCM as `<key id>:<base64 nonce and ciphertext>`. The field key and
+// key id are bound to the ciphertext as additional data. Use `Decrypt` to recover the value.
+func Encrypted(key string, val string) Field {
+	privacyMu.RLock()
+	id, aead := encryptionKeyID, encryptionAEAD
+	privacyMu.RUnlock()
+
+	if aead == nil {
+		return String(key, RedactedValue)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return String(key, RedactedValue)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(val), additionalData(key, id))
+	return exactString(key, id+":"+base64.RawStdEncoding.EncodeToString(sealed))
+}
+
+// Decrypt reverses `Encrypted` for the given field key, looking up the AES key by the id found in the value.
+func Decrypt(keys map[string][]byte, key, val string) (string, error) {
+	i := strings.LastIndexByte(val, ':')
+	if i < 0 {
+		return "", errMalformed
+	}
+	id := val[:i]
+
+	secret, ok := keys[id]
+	if !ok {
+		return "", errUnknownKeyID
+	}
+
+	aead, err := newAEAD(secret)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(val[i+1:])
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errMalformed
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, additionalData(key, id))
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func newAEAD(secret []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func additionalData(key, id string) []byte {
+	return []byte(id + "\x00" + key)
+}
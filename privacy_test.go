@@ -0,0 +1,70 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestHashed(t *testing.T) {
+	SetHashKey("k1", []byte("secret"))
+	defer SetHashKey("", nil)
+
+	a, b := Hashed("user", "42"), Hashed("user", "42")
+	if a.str != b.str {
+		t.Fatal("expected deterministic output")
+	}
+	if !strings.HasPrefix(a.str, "k1:") || strings.Contains(a.str, "42") {
+		t.Fatalf("unexpected hash: %s", a.str)
+	}
+
+	SetHashKey("k2", []byte("rotated"))
+	if c := Hashed("user", "42"); c.str == a.str || !strings.HasPrefix(c.str, "k2:") {
+		t.Fatalf("expected rotated key to change output: %s", c.str)
+	}
+}
+
+func TestEncrypted(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	if err := SetEncryptionKey("2026-10", secret); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { encryptionAEAD, MaxStringLength = nil, 0 }()
+
+	ogWriter := Writer
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	MaxStringLength = 8
+	Info("login", Encrypted("email", "user@example.com"))
+	Writer = ogWriter
+
+	var jData map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &jData); err != nil {
+		t.Fatal(err)
+	}
+
+	val := jData["email"].(string)
+	if strings.Contains(val, "user@example.com") {
+		t.Fatal("plain text leaked")
+	}
+
+	plain, err := Decrypt(map[string][]byte{"2026-10": secret}, "email", val)
+	if err != nil || plain != "user@example.com" {
+		t.Fatalf("unexpected decrypt result: %q %v", plain, err)
+	}
+
+	if _, err := Decrypt(map[string][]byte{"2026-10": secret}, "other", val); err == nil {
+		t.Fatal("expected field key to be authenticated")
+	}
+}
+
+func TestPrivacyWithoutKey(t *testing.T) {
+	if f := Hashed("user", "42"); f.str != RedactedValue {
+		t.Fatal()
+	}
+	if f := Encrypted("user", "42"); f.str != RedactedValue {
+		t.Fatal()
+	}
+}
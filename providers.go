@@ -0,0 +1,90 @@
+package slog
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A FieldProvider returns fields that are evaluated for every log entry.
+type FieldProvider func() []Field
+
+var (
+	providers      atomic.Value // []registeredProvider
+	nextProviderID uint64
+)
+
+type registeredProvider struct {
+	id uint64
+	fn FieldProvider
+}
+
+func init() {
+	providers.Store([]registeredProvider(nil))
+}
+
+func loadProviders() []registeredProvider {
+	return providers.Load().([]registeredProvider)
+}
+
+// AddGlobalFieldProvider registers a provider whose fields are appended to all messages after the global fields.
+// Calling the returned func unregisters it.
+func AddGlobalFieldProvider(p FieldProvider) (remove func()) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	nextProviderID++
+	id := nextProviderID
+
+	current := loadProviders()
+	next := make([]registeredProvider, 0, len(current)+1)
+	next = append(next, current...)
+	next = append(next, registeredProvider{id: id, fn: p})
+	providers.Store(next)
+
+	return func() { removeGlobalFieldProvider(id) }
+}
+
+func removeGlobalFieldProvider(id uint64) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	current := loadProviders()
+	next := make([]registeredProvider, 0, len(current))
+	for _, rp := range current {
+		if rp.id != id {
+			next = append(next, rp)
+		}
+	}
+	providers.Store(next)
+}
+
+// CachedFieldProvider wraps the provider so it is only evaluated once per interval, handing out the cached fields
+// in between. Use it for values that are costly to compute but change slowly.
+func CachedFieldProvider(interval time.Duration, p FieldProvider) FieldProvider {
+	var (
+		mu      sync.Mutex
+		fields  atomic.Value // []Field
+		expires int64        // unix nanos, accessed atomically
+	)
+	fields.Store([]Field(nil))
+
+	return func() []Field {
+		now := time.Now().UnixNano()
+		if now < atomic.LoadInt64(&expires) {
+			return fields.Load().([]Field)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		// Another caller may have refreshed while we waited.
+		if now < atomic.LoadInt64(&expires) {
+			return fields.Load().([]Field)
+		}
+
+		fields.Store(p())
+		atomic.StoreInt64(&expires, now+int64(interval))
+		return fields.Load().([]Field)
+	}
+}
@@ -0,0 +1,53 @@
+package slog
+
+import (
+	"context"
+	"fmt"
+)
+
+var (
+	// RepanicOnRecover makes `Recover` panic again with the original value once it has been logged.
+	RepanicOnRecover = false
+
+	// OnRecover is called by `Recover` with the recovered value once it has been logged.
+	OnRecover func(recovered interface{})
+)
+
+// Recover logs a panic in the current goroutine at the panic severity, along with its stack. It must be deferred
+// directly, as in `defer slog.Recover(fields...)`.
+func Recover(fields ...Field) {
+	if r := recover(); r != nil {
+		logRecovered(r, fields)
+	}
+}
+
+// Go runs fn in a new goroutine, logging any panic along with the request token carried by the context.
+func Go(ctx context.Context, fn func(ctx context.Context)) {
+	go func() {
+		defer Recover(RequestContext(ctx))
+		fn(ctx)
+	}()
+}
+
+func logRecovered(r interface{}, fields []Field) {
+	// Skip past logRecovered and Recover, the runtime panic frames are left to StackFilterRuntime.
+	stack := stackField(4)
+	recoveredFields := append(fields[:len(fields):len(fields)], stack)
+
+	// The caller is the frame that panicked rather than the deferred call.
+	if frames, ok := stack.obj.([]stackFrame); ok && EnableCaller && len(frames) > 0 {
+		top := newCallerInfo(frames[0].file, frames[0].line, frames[0].function)
+		recoveredFields = append(recoveredFields, Field{key: CallerKey, fieldType: callerType, obj: top})
+	}
+
+	logMessage(SeverityPanic, fmt.Sprint(r), recoveredFields)
+	_ = Sync()
+
+	repanic := RepanicOnRecover
+	if OnRecover != nil {
+		OnRecover(r)
+	}
+	if repanic {
+		panic(r)
+	}
+}
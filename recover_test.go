@@ -0,0 +1,70 @@
+package slog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func panickingWorker(ctx context.Context) {
+	panic("worker failed")
+}
+
+func TestGoRecover(t *testing.T) {
+	ogWriter := Writer
+	defer func() {
+		Writer = ogWriter
+		OnRecover = nil
+	}()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+
+	done := make(chan interface{})
+	OnRecover = func(r interface{}) { done <- r }
+
+	Go(WithRequestID(context.Background(), "abc123"), panickingWorker)
+	if r := <-done; r != "worker failed" {
+		t.Fatalf("unexpected recovered value: %v", r)
+	}
+
+	var jData struct {
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+		ReqID string `json:"reqID"`
+		Stack []struct {
+			Func string `json:"func"`
+		} `json:"stack"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &jData); err != nil {
+		t.Fatalf("%v: %s", err, b.String())
+	}
+	if jData.Level != SeverityPanic || jData.Msg != "worker failed" || jData.ReqID != "abc123" {
+		t.Fatalf("unexpected output: %s", b.String())
+	}
+	if len(jData.Stack) == 0 || jData.Stack[0].Func != "github.com/unrolled/slog.panickingWorker" {
+		t.Fatalf("expected stack to start at the panic: %s", b.String())
+	}
+}
+
+func TestRecoverRepanic(t *testing.T) {
+	ogWriter := Writer
+	defer func() {
+		Writer = ogWriter
+		RepanicOnRecover = false
+	}()
+
+	Writer = DiscardWrapper
+	RepanicOnRecover = true
+
+	defer func() {
+		if r := recover(); r != "again" {
+			t.Fatalf("expected panic to be re-raised: %v", r)
+		}
+	}()
+	func() {
+		defer Recover()
+		panic("again")
+	}()
+}
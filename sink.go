@@ -0,0 +1,104 @@
+package slog
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	sinkMu sync.Mutex
+	sinks  atomic.Value // []sink
+
+	hooksMu   sync.Mutex
+	exitHooks []func()
+)
+
+type sink struct {
+	ws  WriteSyncer
+	enc Encoder
+}
+
+// A SinkOption configures a sink added with `AddSink`.
+type SinkOption func(*sink)
+
+// WithEncoder formats the messages written to the sink with enc instead of `DefaultEncoder`.
+func WithEncoder(enc Encoder) SinkOption {
+	return func(s *sink) {
+		s.enc = enc
+	}
+}
+
+func init() {
+	sinks.Store([]sink(nil))
+}
+
+func loadSinks() []sink {
+	return sinks.Load().([]sink)
+}
+
+// AddSink adds a destination that receives every message in addition to `Writer`.
+func AddSink(ws WriteSyncer, opts ...SinkOption) {
+	s := sink{ws: ws}
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+
+	current := loadSinks()
+	next := make([]sink, 0, len(current)+1)
+	next = append(next, current...)
+	next = append(next, s)
+	sinks.Store(next)
+}
+
+// Sync writes any batched metrics and flushes `Writer` and every sink, returning the first error.
+func Sync() error {
+	FlushMetrics()
+
+	err := Writer.Sync()
+	for _, s := range loadSinks() {
+		if serr := s.ws.Sync(); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+// RegisterExitHook adds a func that `Fatal` runs before flushing and exiting. Hooks run in the order they were
+// registered, and `Fatal` stops waiting on them after `ExitHookTimeout`.
+func RegisterExitHook(fn func()) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+
+	exitHooks = append(exitHooks, fn)
+}
+
+func runExitHooks() {
+	hooksMu.Lock()
+	hooks := make([]func(), len(exitHooks))
+	copy(hooks, exitHooks)
+	hooksMu.Unlock()
+
+	if len(hooks) == 0 {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, fn := range hooks {
+			fn()
+		}
+	}()
+
+	timer := time.NewTimer(ExitHookTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+}
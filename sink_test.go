@@ -0,0 +1,84 @@
+package slog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingSyncer struct {
+	bytes.Buffer
+	syncs int
+}
+
+func (r *recordingSyncer) Sync() error {
+	r.syncs++
+	return nil
+}
+
+func TestFatal(t *testing.T) {
+	ogWriter, ogExit := Writer, ExitFunc
+	defer func() {
+		Writer, ExitFunc = ogWriter, ogExit
+		sinks.Store([]sink(nil))
+		exitHooks = nil
+	}()
+
+	primary, extra := &recordingSyncer{}, &recordingSyncer{}
+	Writer = primary
+	AddSink(extra)
+
+	var order []string
+	RegisterExitHook(func() { order = append(order, "hook") })
+	ExitFunc = func(code int) { order = append(order, "exit") }
+
+	Fatal("shutting down")
+
+	if strings.Join(order, ",") != "hook,exit" {
+		t.Fatalf("unexpected order: %v", order)
+	}
+	for _, r := range []*recordingSyncer{primary, extra} {
+		if !strings.Contains(r.String(), `"level":"fatal", "msg":"shutting down"`) || r.syncs != 1 {
+			t.Fatalf("expected message to be written and flushed: %q (%d syncs)", r.String(), r.syncs)
+		}
+	}
+}
+
+func TestFatalHookTimeout(t *testing.T) {
+	ogWriter, ogExit, ogTimeout := Writer, ExitFunc, ExitHookTimeout
+	defer func() {
+		Writer, ExitFunc, ExitHookTimeout = ogWriter, ogExit, ogTimeout
+		exitHooks = nil
+	}()
+
+	Writer = DiscardWrapper
+	ExitHookTimeout = 10 * time.Millisecond
+	block := make(chan struct{})
+	defer close(block)
+	RegisterExitHook(func() { <-block })
+
+	exited := false
+	ExitFunc = func(code int) { exited = code == 1 }
+
+	Fatal("stuck")
+	if !exited {
+		t.Fatal("expected exit after the hook timed out")
+	}
+}
+
+func TestPanic(t *testing.T) {
+	ogWriter, ogPanic := Writer, PanicFunc
+	defer func() { Writer, PanicFunc = ogWriter, ogPanic }()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+
+	var got string
+	PanicFunc = func(message string) { got = message }
+
+	Panic("boom")
+	if got != "boom" || !strings.Contains(b.String(), `"level":"panic"`) {
+		t.Fatalf("unexpected panic handling: %q %s", got, b.String())
+	}
+}
@@ -0,0 +1,153 @@
+package slog
+
+import (
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+type stackFrame struct {
+	function string
+	file     string
+	line     int
+}
+
+// stackField returns the stack starting skip levels above stackField, as configured by StackDepth and
+// StackFilterRuntime.
+func stackField(skip int) Field {
+	depth := StackDepth
+	if depth <= 0 {
+		return Skip()
+	}
+
+	// Grab extra frames up front so filtering still leaves a full stack.
+	pc := make([]uintptr, depth*2)
+	n := runtime.Callers(skip, pc)
+	frames := runtime.CallersFrames(pc[:n])
+
+	stack := make([]stackFrame, 0, depth)
+	for len(stack) < depth {
+		frame, more := frames.Next()
+		if !StackFilterRuntime || !isStdlibFrame(frame) {
+			stack = append(stack, stackFrame{function: frame.Function, file: frame.File, line: frame.Line})
+		}
+		if !more {
+			break
+		}
+	}
+
+	return Field{key: StackKey, fieldType: stackType, obj: stack}
+}
+
+// gorootSrc is where the runtime and standard library sources live, or empty when the binary was built with
+// -trimpath. Frames are reported with forward slashes on every platform.
+var gorootSrc = func() string {
+	if goroot := runtime.GOROOT(); goroot != "" {
+		return strings.TrimSuffix(filepath.ToSlash(goroot), "/") + "/src/"
+	}
+	return ""
+}()
+
+// mainModule is the path of the main module, whose trimmed file paths may look like those of the standard library.
+var mainModule = func() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return info.Main.Path
+	}
+	return ""
+}()
+
+// isStdlibFrame reports whether the frame belongs to the runtime or standard library. Untrimmed files are judged
+// by whether they are under GOROOT. Files of binaries built with -trimpath start with the import path instead,
+// where the standard library is the one without a dot in its first element, and without the version that
+// dependencies carry. The main module is checked on its own, since it may be named without a dot.
+func isStdlibFrame(frame runtime.Frame) bool {
+	file := frame.File
+	if file == "" {
+		return true
+	}
+	if gorootSrc != "" && strings.HasPrefix(file, gorootSrc) {
+		return true
+	}
+	if strings.HasPrefix(file, "/") || (len(file) > 2 && file[1] == ':') {
+		return false
+	}
+	if mainModule != "" && strings.HasPrefix(file, mainModule+"/") {
+		return false
+	}
+
+	first := file
+	if i := strings.IndexByte(first, '/'); i >= 0 {
+		first = first[:i]
+	}
+	return !strings.ContainsAny(first, ".@")
+}
+
+func encodeStack(enc Encoder, b *Buffer, key string, stack []stackFrame) {
+	enc.BeginArray(b, key)
+	for _, frame := range stack {
+		enc.BeginObject(b, "")
+		enc.AddString(b, "func", frame.function)
+		enc.AddString(b, "file", frame.file)
+		enc.AddInt64(b, "line", int64(frame.line))
+		enc.EndObject(b)
+	}
+	enc.EndArray(b)
+}
+
+// severityRank orders the built in severities, returning -1 for custom ones.
+func severityRank(s string) int {
+	switch s {
+	case SeverityDebug:
+		return 0
+	case SeverityInfo:
+		return 1
+	case SeverityWarn:
+		return 2
+	case SeverityError:
+		return 3
+	case SeverityPanic:
+		return 4
+	case SeverityFatal:
+		return 5
+	}
+	return -1
+}
+
+// wantsStack reports whether a message of the given severity gets a stack added automatically.
+func wantsStack(severity string, fields []Field) bool {
+	if StackSeverity == "" {
+		return false
+	}
+
+	threshold, rank := severityRank(StackSeverity), severityRank(severity)
+	if threshold < 0 || rank < threshold {
+		return false
+	}
+
+	return !hasFieldType(fields, stackType)
+}
+
+func hasFieldType(fields []Field, ft fieldType) bool {
+	for _, f := range fields {
+		if f.fieldType == ft {
+			return true
+		}
+	}
+	return false
+}
+
+// formatStack writes the stack the way Go prints one, with each function followed by its indented location.
+func formatStack(stack []stackFrame) string {
+	var b strings.Builder
+	for _, frame := range stack {
+		b.WriteString(frame.function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.file)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.line))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
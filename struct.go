@@ -0,0 +1,307 @@
+package slog
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxStructDepth stops runaway recursion on self referencing values.
+const maxStructDepth = 32
+
+var (
+	structCache sync.Map // map[reflect.Type]*structInfo
+
+	timeType          = reflect.TypeOf(time.Time{})
+	errorIfce         = reflect.TypeOf((*error)(nil)).Elem()
+	jsonMarshalerIfce = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerIfce = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+type structInfo struct {
+	fields []structField
+}
+
+type structField struct {
+	index     []int
+	name      string
+	omitEmpty bool
+	redact    bool
+}
+
+// Struct outputs the value as a nested object, honoring `slog:"name,omitempty"`, `slog:"-"` and
+// `slog:",redact"` struct tags. Unexported fields are ignored.
+func Struct(key string, val interface{}) Field {
+	if val == nil {
+		return Skip()
+	}
+	return Field{key: key, fieldType: structType, obj: val}
+}
+
+func encodeStruct(enc Encoder, b *Buffer, key string, val interface{}) {
+	encodeReflectValue(enc, b, key, reflect.ValueOf(val), 0)
+}
+
+func cachedStructInfo(t reflect.Type) *structInfo {
+	if si, ok := structCache.Load(t); ok {
+		return si.(*structInfo)
+	}
+
+	si := &structInfo{fields: collectStructFields(t, nil)}
+	actual, _ := structCache.LoadOrStore(t, si)
+	return actual.(*structInfo)
+}
+
+func collectStructFields(t reflect.Type, parent []int) []structField {
+	var fields []structField
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("slog")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts := tag, ""
+		if idx := strings.IndexByte(tag, ','); idx >= 0 {
+			name, opts = tag[:idx], tag[idx+1:]
+		}
+
+		index := make([]int, len(parent)+1)
+		copy(index, parent)
+		index[len(parent)] = i
+
+		// Flatten untagged embedded structs the same way encoding/json does.
+		if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, collectStructFields(sf.Type, index)...)
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+
+		f := structField{index: index, name: name}
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "omitempty":
+				f.omitEmpty = true
+			case "redact":
+				f.redact = true
+			}
+		}
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+func encodeReflectValue(enc Encoder, b *Buffer, key string, v reflect.Value, depth int) {
+	if !v.IsValid() || depth > maxStructDepth {
+		enc.AddNull(b, key)
+		return
+	}
+
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		enc.AddNull(b, key)
+		return
+	}
+	if v.Type() == timeType {
+		encodeTime(enc, b, key, v.Interface().(time.Time))
+		return
+	}
+	if encodeMarshaler(enc, b, key, v) {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		encodeReflectValue(enc, b, key, v.Elem(), depth+1)
+	case reflect.Struct:
+		encodeStructValue(enc, b, key, v, depth)
+	case reflect.Map:
+		encodeMapValue(enc, b, key, v, depth)
+	case reflect.Slice:
+		if v.IsNil() {
+			enc.AddNull(b, key)
+			return
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			enc.AddString(b, key, base64.StdEncoding.EncodeToString(v.Bytes()))
+			return
+		}
+		encodeArrayValue(enc, b, key, v, depth)
+	case reflect.Array:
+		encodeArrayValue(enc, b, key, v, depth)
+	case reflect.Bool:
+		enc.AddBool(b, key, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		enc.AddInt64(b, key, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		enc.AddUint64(b, key, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		enc.AddFloat64(b, key, v.Float())
+	case reflect.String:
+		enc.AddString(b, key, b.truncate(v.String(), MaxStringLength))
+	default:
+		if out, err := json.Marshal(v.Interface()); err == nil {
+			enc.AddRawJSON(b, key, out)
+		} else {
+			enc.AddString(b, key, fmt.Sprintf("%#v", v.Interface()))
+		}
+	}
+}
+
+// encodeMarshaler writes values that know how to represent themselves the way encoding/json would, checking
+// json.Marshaler, then encoding.TextMarshaler, then error. It returns false for any other value.
+func encodeMarshaler(enc Encoder, b *Buffer, key string, v reflect.Value) bool {
+	if v.Kind() != reflect.Interface && !isMarshaler(v.Type()) {
+		// Like encoding/json, methods with a pointer receiver are used when the value is addressable.
+		if !v.CanAddr() || !isMarshaler(reflect.PtrTo(v.Type())) {
+			return false
+		}
+		v = v.Addr()
+	}
+	if !v.CanInterface() {
+		return false
+	}
+
+	switch m := v.Interface().(type) {
+	case json.Marshaler:
+		if out, err := json.Marshal(m); err == nil {
+			enc.AddRawJSON(b, key, out)
+		} else {
+			enc.AddString(b, key, fmt.Sprintf("%#v", m))
+		}
+	case encoding.TextMarshaler:
+		if text, err := m.MarshalText(); err == nil {
+			enc.AddString(b, key, b.truncate(string(text), MaxStringLength))
+		} else {
+			enc.AddString(b, key, fmt.Sprintf("%#v", m))
+		}
+	case error:
+		enc.AddString(b, key, b.truncate(m.Error(), MaxStringLength))
+	default:
+		return false
+	}
+	return true
+}
+
+func isMarshaler(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerIfce) || t.Implements(textMarshalerIfce) || t.Implements(errorIfce)
+}
+
+func encodeStructValue(enc Encoder, b *Buffer, key string, v reflect.Value, depth int) {
+	si := cachedStructInfo(v.Type())
+
+	enc.BeginObject(b, key)
+	for _, f := range si.fields {
+		fv := v.FieldByIndex(f.index)
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+
+		if f.redact {
+			enc.AddString(b, f.name, RedactedValue)
+		} else {
+			encodeReflectValue(enc, b, f.name, fv, depth+1)
+		}
+	}
+	enc.EndObject(b)
+}
+
+func encodeMapValue(enc Encoder, b *Buffer, key string, v reflect.Value, depth int) {
+	if v.IsNil() {
+		enc.AddNull(b, key)
+		return
+	}
+
+	// Keys are sorted, as encoding/json does, so the same map always makes the same line.
+	keys := v.MapKeys()
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = mapKeyString(k)
+	}
+	sort.Sort(mapKeys{keys: keys, names: names})
+
+	enc.BeginObject(b, key)
+	for i, k := range keys {
+		encodeReflectValue(enc, b, names[i], v.MapIndex(k), depth+1)
+	}
+	enc.EndObject(b)
+}
+
+func encodeArrayValue(enc Encoder, b *Buffer, key string, v reflect.Value, depth int) {
+	enc.BeginArray(b, key)
+	for i := 0; i < v.Len(); i++ {
+		encodeReflectValue(enc, b, "", v.Index(i), depth+1)
+	}
+	enc.EndArray(b)
+}
+
+func encodeTime(enc Encoder, b *Buffer, key string, val time.Time) {
+	if len(TimeFormat) > 0 {
+		enc.AddString(b, key, val.Format(TimeFormat))
+		return
+	}
+
+	enc.AddInt64(b, key, val.Unix())
+}
+
+// mapKeys sorts map keys by their string form.
+type mapKeys struct {
+	keys  []reflect.Value
+	names []string
+}
+
+func (m mapKeys) Len() int           { return len(m.keys) }
+func (m mapKeys) Less(i, j int) bool { return m.names[i] < m.names[j] }
+func (m mapKeys) Swap(i, j int) {
+	m.keys[i], m.keys[j] = m.keys[j], m.keys[i]
+	m.names[i], m.names[j] = m.names[j], m.names[i]
+}
+
+func mapKeyString(k reflect.Value) string {
+	switch k.Kind() {
+	case reflect.String:
+		return k.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10)
+	default:
+		return fmt.Sprint(k.Interface())
+	}
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v.Interface().(time.Time).IsZero()
+		}
+	}
+	return false
+}
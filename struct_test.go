@@ -0,0 +1,93 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+)
+
+type structAudit struct {
+	Version int
+}
+
+type structUser struct {
+	structAudit
+	ID       int               `slog:"id"`
+	Name     string            `slog:"name,omitempty"`
+	Password string            `slog:"-"`
+	Email    string            `slog:"email,redact"`
+	Tags     []string          `slog:"tags"`
+	Meta     map[string]string `slog:"meta,omitempty"`
+	Parent   *structUser       `slog:"parent"`
+	internal string
+}
+
+func TestStruct(t *testing.T) {
+	ogWriter := Writer
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	Info("user", Struct("user", &structUser{
+		structAudit: structAudit{Version: 3},
+		ID:          42,
+		Password:    "hunter2",
+		Email:       "user@example.com",
+		Tags:        []string{"a", "b"},
+		internal:    "hidden",
+	}))
+	Writer = ogWriter
+
+	if strings.Contains(b.String(), "hunter2") || strings.Contains(b.String(), "user@example.com") || strings.Contains(b.String(), "hidden") {
+		t.Fatalf("hidden values leaked: %s", b.String())
+	}
+
+	var jData struct {
+		User map[string]interface{} `json:"user"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &jData); err != nil {
+		t.Fatalf("%v: %s", err, b.String())
+	}
+
+	u := jData.User
+	if u["id"] != float64(42) || u["Version"] != float64(3) || u["email"] != RedactedValue || u["parent"] != nil {
+		t.Fatalf("unexpected output: %s", b.String())
+	}
+	if _, ok := u["name"]; ok {
+		t.Fatal("expected omitempty field to be omitted")
+	}
+	if tags, ok := u["tags"].([]interface{}); !ok || len(tags) != 2 {
+		t.Fatalf("unexpected tags: %v", u["tags"])
+	}
+}
+
+func TestStructMarshalers(t *testing.T) {
+	ogWriter, ogEncoder := Writer, DefaultEncoder
+	defer func() { Writer, DefaultEncoder = ogWriter, ogEncoder }()
+
+	var b bytes.Buffer
+	Writer = traceSyncWrapper{&b}
+	DefaultEncoder = NewCompactJSONEncoder()
+
+	Info("marshalers", Struct("v", struct {
+		IP    net.IP          `slog:"ip"`
+		Big   *big.Int        `slog:"big"`
+		Raw   json.RawMessage `slog:"raw"`
+		Err   error           `slog:"err"`
+		Order map[string]int  `slog:"order"`
+	}{
+		IP:    net.IPv4(10, 0, 0, 1),
+		Big:   big.NewInt(12345),
+		Raw:   json.RawMessage(`{"a":1}`),
+		Err:   errors.New("boom"),
+		Order: map[string]int{"c": 3, "a": 1, "b": 2, "d": 4},
+	}))
+
+	expected := `"v":{"ip":"10.0.0.1","big":12345,"raw":{"a":1},"err":"boom","order":{"a":1,"b":2,"c":3,"d":4}}`
+	if !strings.Contains(b.String(), expected) {
+		t.Fatalf("unexpected output:\n%s\nexpected:\n%s", b.String(), expected)
+	}
+}
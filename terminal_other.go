@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!windows
+
+package slog
+
+import "os"
+
+// isTerminal reports false where there is no way to tell, so the output stays JSON.
+func isTerminal(f *os.File) bool {
+	return false
+}
@@ -0,0 +1,12 @@
+package slog
+
+import (
+	"os"
+	"syscall"
+)
+
+// isTerminal reports whether f is a console, which only consoles have a mode for.
+func isTerminal(f *os.File) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(f.Fd()), &mode) == nil
+}
@@ -0,0 +1,61 @@
+package slog
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// TraceParentHeader is the W3C trace context header that `TraceParent` reads.
+const TraceParentHeader = "traceparent"
+
+type traceContext struct {
+	traceID string
+	spanID  string
+	sampled bool
+}
+
+// TraceParent outputs the trace and span IDs from a W3C `traceparent` header value, such as
+// `00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01`. Invalid values are skipped.
+func TraceParent(header string) Field {
+	tc, ok := parseTraceParent(header)
+	if !ok {
+		return Skip()
+	}
+	return Field{fieldType: traceType, obj: tc}
+}
+
+// RequestTrace is the `TraceParent` field for the header of an incoming request.
+func RequestTrace(r *http.Request) Field {
+	return TraceParent(r.Header.Get(TraceParentHeader))
+}
+
+func parseTraceParent(header string) (*traceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return nil, false
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isLowerHex(version) || !isLowerHex(flags) || len(flags) != 2 {
+		return nil, false
+	}
+	if len(traceID) != 32 || !isLowerHex(traceID) || strings.Trim(traceID, "0") == "" {
+		return nil, false
+	}
+	if len(spanID) != 16 || !isLowerHex(spanID) || strings.Trim(spanID, "0") == "" {
+		return nil, false
+	}
+
+	f, _ := strconv.ParseUint(flags, 16, 8)
+	return &traceContext{traceID: traceID, spanID: spanID, sampled: f&1 == 1}, true
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}